@@ -0,0 +1,197 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ArchiveSeparator separates the path of an archive from the path of an entry
+// inside it, e.g. release.tgz!/src/main.go
+const ArchiveSeparator = "!/"
+
+// Archive formats read with --archives
+const (
+	archiveZip     = "zip"
+	archiveTar     = "tar"
+	archiveTarGzip = "tar.gz"
+	archiveTarBz2  = "tar.bz2"
+)
+
+// archiveSuffixes maps file name suffixes to archive formats. Formats without
+// a decompressor in the standard library, such as .tar.xz, are not read.
+var archiveSuffixes = []struct {
+	suffix string
+	format string
+}{
+	{".zip", archiveZip},
+	{".jar", archiveZip},
+	{".tar", archiveTar},
+	{".tar.gz", archiveTarGzip},
+	{".tgz", archiveTarGzip},
+	{".tar.bz2", archiveTarBz2},
+	{".tbz2", archiveTarBz2},
+}
+
+// archiveFormat returns the archive format of a file name, or an empty string
+// if it is not a readable archive
+func archiveFormat(name string) string {
+	lower := strings.ToLower(name)
+	for _, s := range archiveSuffixes {
+		if strings.HasSuffix(lower, s.suffix) {
+			return s.format
+		}
+	}
+	return ""
+}
+
+// archiveEntry is a regular file inside an archive
+type archiveEntry struct {
+	// name is the slash separated path of the entry inside the archive
+	name string
+	size int64
+	r    *bufio.Reader
+	// err is set, and r nil, if the entry could not be opened
+	err error
+}
+
+// Size returns the uncompressed size of the entry
+func (e *archiveEntry) Size() (int64, error) {
+	return e.size, nil
+}
+
+// IsBinary reports whether the start of the entry contains a NUL byte,
+// without consuming it
+func (e *archiveEntry) IsBinary() (bool, error) {
+	buf, err := e.r.Peek(binarySniffSize)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return bytes.IndexByte(buf, 0) >= 0, nil
+}
+
+// readArchive calls fn for every regular file in an archive, in archive order.
+// The archive is read from disk if fsys is nil and from fsys otherwise. A zip
+// entry that cannot be opened is passed with its error, so the other entries
+// are still read.
+func readArchive(fsys fs.FS, archivePath string, fn func(entry *archiveEntry) error) error {
+	file, err := openPath(fsys, archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	format := archiveFormat(archivePath)
+	if format == archiveZip {
+		return readZip(file, fn)
+	}
+
+	var r io.Reader = file
+	switch format {
+	case archiveTarGzip:
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case archiveTarBz2:
+		r = bzip2.NewReader(file)
+	case archiveTar:
+	default:
+		return fmt.Errorf("%s is not a supported archive", archivePath)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		name := cleanEntryName(header.Name)
+		if name == "" {
+			continue
+		}
+		entry := &archiveEntry{name: name, size: header.Size, r: bufio.NewReaderSize(tr, binarySniffSize)}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// readZip calls fn for every regular file in a zip archive. Zip files need
+// random access, so files without it are read into memory.
+func readZip(file fs.File, fn func(entry *archiveEntry) error) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	ra, ok := file.(io.ReaderAt)
+	size := info.Size()
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		name := cleanEntryName(f.Name)
+		if name == "" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			if err := fn(&archiveEntry{name: name, size: int64(f.UncompressedSize64), err: err}); err != nil {
+				return err
+			}
+			continue
+		}
+		entry := &archiveEntry{name: name, size: int64(f.UncompressedSize64), r: bufio.NewReaderSize(rc, binarySniffSize)}
+		err = fn(entry)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimArchiveSeparators removes the separator following archive names in the
+// components of a slash separated path, so entries are grouped under a
+// directory named after their archive
+func trimArchiveSeparators(parts []string) {
+	for i, part := range parts {
+		if name := strings.TrimSuffix(part, "!"); name != part && archiveFormat(name) != "" {
+			parts[i] = name
+		}
+	}
+}
+
+// cleanEntryName normalizes the path of an archive entry to a slash separated
+// path inside the archive, so "../" cannot lead outside it
+func cleanEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+}
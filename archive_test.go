@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// archiveFiles are the entries written to test archives
+var archiveFiles = []struct {
+	name    string
+	content string
+}{
+	{"src/main.go", "package main\n\n// main starts\nfunc main() {}\n"},
+	{"./lib/util.py", "x = 1\n"},
+	{"node_modules/dep/index.js", "var dep = 1\n"},
+	{"data.bin", "\x00\x01"},
+}
+
+func writeTestZip(t *testing.T, path string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	for _, f := range archiveFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestTarGz(t *testing.T, path string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "src/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, f := range archiveFiles {
+		tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.content))})
+		tw.Write([]byte(f.content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveFormat(t *testing.T) {
+	tests := map[string]string{
+		"release.zip":     archiveZip,
+		"lib.JAR":         archiveZip,
+		"src.tar":         archiveTar,
+		"src.tar.gz":      archiveTarGzip,
+		"src.tgz":         archiveTarGzip,
+		"src.tar.bz2":     archiveTarBz2,
+		"src.tar.xz":      "",
+		"notes.gz":        "",
+		"main.go":         "",
+		"archive.zip.txt": "",
+	}
+	for name, want := range tests {
+		if got := archiveFormat(name); got != want {
+			t.Errorf("archiveFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCleanEntryName(t *testing.T) {
+	tests := map[string]string{
+		"src/main.go":     "src/main.go",
+		"./src/main.go":   "src/main.go",
+		"/abs/main.go":    "abs/main.go",
+		"../../escape.go": "escape.go",
+		`win\path\a.go`:   "win/path/a.go",
+		"./":              "",
+	}
+	for name, want := range tests {
+		if got := cleanEntryName(name); got != want {
+			t.Errorf("cleanEntryName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWalkerArchives(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestZip(t, filepath.Join(tmpDir, "drop.zip"))
+	writeTestTarGz(t, filepath.Join(tmpDir, "release.tgz"))
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644)
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetArchives(true)
+	results, errs := walker.Walk()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	var got []string
+	for _, stats := range results {
+		rel, _ := filepath.Rel(tmpDir, stats.FilePath)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{
+		"drop.zip!/lib/util.py",
+		"drop.zip!/src/main.go",
+		"main.go",
+		"release.tgz!/lib/util.py",
+		"release.tgz!/src/main.go",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("counted %q, want %q", got, want)
+	}
+
+	for _, stats := range results {
+		if filepath.Base(stats.FilePath) == "main.go" && stats.FilePath != filepath.Join(tmpDir, "main.go") {
+			if stats.CodeLines != 2 || stats.CommentLines != 1 || stats.BlankLines != 1 {
+				t.Errorf("%s: code=%d comment=%d blank=%d, want 2, 1, 1", stats.FilePath, stats.CodeLines, stats.CommentLines, stats.BlankLines)
+			}
+			if stats.Extension != ".go" {
+				t.Errorf("%s: extension %q, want .go", stats.FilePath, stats.Extension)
+			}
+		}
+	}
+	if n := walker.GetSkipStats().Count(SkipBinary); n != 2 {
+		t.Errorf("binary skips = %d, want 2 for the data.bin entries", n)
+	}
+}
+
+func TestWalkerArchivesDisabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestZip(t, filepath.Join(tmpDir, "drop.zip"))
+
+	walker := NewWalker(tmpDir, 2)
+	results, _ := walker.Walk()
+	if len(results) != 0 {
+		t.Errorf("counted %d files, want archives skipped without --archives", len(results))
+	}
+	if n := walker.GetSkipStats().Count(SkipBinary); n != 1 {
+		t.Errorf("binary skips = %d, want 1", n)
+	}
+}
+
+func TestWalkerArchivesExcluded(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestZip(t, filepath.Join(tmpDir, "drop.zip"))
+	writeTestTarGz(t, filepath.Join(tmpDir, "release.tgz"))
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetArchives(true)
+	filter := NewPathFilter()
+	filter.AddExclude("*.zip")
+	filter.AddExclude("release.tgz/lib")
+	walker.SetPathFilter(filter)
+	results, _ := walker.Walk()
+
+	if len(results) != 1 || filepath.Base(results[0].FilePath) != "main.go" {
+		t.Errorf("counted %d files, want only release.tgz!/src/main.go", len(results))
+	}
+}
+
+func TestWalkerArchiveCorrupt(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "broken.zip"), []byte("not a zip"), 0644)
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetArchives(true)
+	_, errs := walker.Walk()
+	if len(errs) != 1 {
+		t.Errorf("got %d errors, want 1 for the corrupt archive", len(errs))
+	}
+}
+
+func TestWalkerArchiveEntryError(t *testing.T) {
+	tmpDir := t.TempDir()
+	file, err := os.Create(filepath.Join(tmpDir, "drop.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+	// An entry with an unknown compression method cannot be opened
+	if _, err := zw.CreateRaw(&zip.FileHeader{Name: "bad.go", Method: 99}); err != nil {
+		t.Fatal(err)
+	}
+	w, _ := zw.Create("good.go")
+	w.Write([]byte("package good\n"))
+	zw.Close()
+	file.Close()
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetArchives(true)
+	results, errs := walker.Walk()
+	if len(results) != 1 || !strings.HasSuffix(results[0].FilePath, "good.go") {
+		t.Errorf("counted %d files, want good.go after the unreadable entry", len(results))
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "drop.zip!/bad.go") {
+		t.Errorf("errors = %v, want one for drop.zip!/bad.go", errs)
+	}
+}
+
+func TestAggregateDirStatsArchives(t *testing.T) {
+	root := "repo"
+	tree := AggregateDirStats([]*FileStats{
+		{FilePath: filepath.Join(root, "release.tgz") + ArchiveSeparator + "src/main.go", Language: "Go", CodeLines: 2},
+		{FilePath: filepath.Join(root, "data!", "x.go"), Language: "Go", CodeLines: 1},
+	}, root, 0)
+
+	archive := tree.Children["release.tgz"]
+	if archive == nil || archive.Children["src"] == nil || archive.Children["src"].Path != "release.tgz/src" {
+		t.Errorf("archive entries should be grouped under release.tgz, got children %v", tree.Children)
+	}
+	if tree.Children["data!"] == nil {
+		t.Errorf("directories that are not archives keep their name, got children %v", tree.Children)
+	}
+	if got := compareDir("release.tgz!/src/main.go", 1); got != "release.tgz" {
+		t.Errorf("compareDir = %q, want release.tgz", got)
+	}
+}
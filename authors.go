@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// beforeSince is the name of the bucket for lines last changed before --since
+const beforeSince = "(before since)"
+
+// AuthorsConfig holds the configuration of the authors command
+type AuthorsConfig struct {
+	Config
+	Since       string
+	MailmapFile string
+}
+
+// BlameLine holds the last-modifying author of a line as reported by git blame
+type BlameLine struct {
+	Author   string
+	Email    string
+	Boundary bool
+}
+
+// AuthorStats holds aggregated statistics for an author
+type AuthorStats struct {
+	Author    string
+	Stats     LanguageStats
+	Languages map[string]*LanguageStats
+}
+
+// GitBlame runs git blame on a file inside a repository and returns the author
+// of every line, keyed by 1-based line number. Lines last changed before since
+// are marked as boundary lines. Authors are resolved through the repository's
+// mailmap, or mailmapFile if set.
+func GitBlame(repoPath, relPath, since, mailmapFile string) (map[int]BlameLine, error) {
+	args := []string{"-C", repoPath}
+	if mailmapFile != "" {
+		args = append(args, "-c", "mailmap.file="+mailmapFile)
+	}
+	args = append(args, "blame", "--line-porcelain", "--root")
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	args = append(args, "--", filepath.ToSlash(relPath))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git blame %s: %s", relPath, msg)
+		}
+		return nil, fmt.Errorf("git blame %s: %v", relPath, err)
+	}
+
+	return parseBlamePorcelain(bytes.NewReader(out))
+}
+
+// parseBlamePorcelain parses the output of git blame --line-porcelain
+func parseBlamePorcelain(r io.Reader) (map[int]BlameLine, error) {
+	lines := make(map[int]BlameLine)
+
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	var current BlameLine
+	lineNum := 0
+	inHeader := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Content lines are prefixed with a tab and end a line's entry
+		if strings.HasPrefix(line, "\t") {
+			lines[lineNum] = current
+			inHeader = false
+			continue
+		}
+
+		if !inHeader {
+			// "<sha> <orig line> <final line> [<group size>]" starts an entry
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unexpected blame header: %q", line)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected blame header: %q", line)
+			}
+			lineNum = n
+			current = BlameLine{}
+			inHeader = true
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "author "):
+			current.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-mail "):
+			current.Email = strings.Trim(strings.TrimPrefix(line, "author-mail "), "<>")
+		case line == "boundary":
+			current.Boundary = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// authorKey returns the name an author is reported under
+func authorKey(line BlameLine) string {
+	if line.Boundary {
+		return beforeSince
+	}
+	if line.Email == "" {
+		return line.Author
+	}
+	return line.Author + " <" + line.Email + ">"
+}
+
+// BlameFile attributes every code and comment line of a file to its last
+// author and returns the counts per author
+func BlameFile(repoPath, filePath string, lang *Language, since, mailmapFile string) (map[string]*AuthorStats, error) {
+	relPath, err := filepath.Rel(repoPath, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+
+	blame, err := GitBlame(repoPath, relPath, since, mailmapFile)
+	if err != nil {
+		return nil, err
+	}
+
+	authorStats := make(map[string]*AuthorStats)
+	fileSeen := make(map[string]bool)
+
+	_, err = ClassifyLines(filePath, lang, func(lineNum int, kind LineKind) {
+		if kind == LineBlank {
+			return
+		}
+
+		line, ok := blame[lineNum]
+		if !ok {
+			return
+		}
+
+		key := authorKey(line)
+		stats, exists := authorStats[key]
+		if !exists {
+			stats = newAuthorStats(key)
+			authorStats[key] = stats
+		}
+		if _, exists := stats.Languages[lang.Name]; !exists {
+			stats.Languages[lang.Name] = &LanguageStats{Language: lang.Name}
+		}
+		langStats := stats.Languages[lang.Name]
+
+		if !fileSeen[key] {
+			fileSeen[key] = true
+			stats.Stats.FileCount++
+			langStats.FileCount++
+		}
+
+		if kind == LineCode {
+			stats.Stats.CodeLines++
+			langStats.CodeLines++
+		} else {
+			stats.Stats.CommentLines++
+			langStats.CommentLines++
+		}
+		stats.Stats.TotalLines++
+		langStats.TotalLines++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return authorStats, nil
+}
+
+// newAuthorStats creates an empty AuthorStats
+func newAuthorStats(author string) *AuthorStats {
+	return &AuthorStats{
+		Author:    author,
+		Stats:     LanguageStats{Language: author},
+		Languages: make(map[string]*LanguageStats),
+	}
+}
+
+// mergeAuthorStats adds the statistics of src into dst
+func mergeAuthorStats(dst, src map[string]*AuthorStats) {
+	for key, stats := range src {
+		target, exists := dst[key]
+		if !exists {
+			target = newAuthorStats(key)
+			dst[key] = target
+		}
+		target.Stats.merge(&stats.Stats)
+		for lang, ls := range stats.Languages {
+			if _, exists := target.Languages[lang]; !exists {
+				target.Languages[lang] = &LanguageStats{Language: lang}
+			}
+			target.Languages[lang].merge(ls)
+		}
+	}
+}
+
+// BusFactor returns the smallest number of authors who together last modified
+// more than half of the code lines. Lines from before --since are not counted.
+func BusFactor(authorStats map[string]*AuthorStats) int {
+	total := 0
+	codes := make([]int, 0, len(authorStats))
+	for key, stats := range authorStats {
+		if key == beforeSince {
+			continue
+		}
+		total += stats.Stats.CodeLines
+		codes = append(codes, stats.Stats.CodeLines)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(codes)))
+
+	covered := 0
+	for i, code := range codes {
+		covered += code
+		if covered*2 > total {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// sortAuthorsByCode sorts authors by code lines in descending order
+func sortAuthorsByCode(authorStats map[string]*AuthorStats) []string {
+	authors := make([]string, 0, len(authorStats))
+	for author := range authorStats {
+		authors = append(authors, author)
+	}
+
+	sort.Slice(authors, func(i, j int) bool {
+		a, b := authorStats[authors[i]], authorStats[authors[j]]
+		if a.Stats.CodeLines != b.Stats.CodeLines {
+			return a.Stats.CodeLines > b.Stats.CodeLines
+		}
+		return authors[i] < authors[j]
+	})
+
+	return authors
+}
+
+// RunAuthors executes the authors command
+func RunAuthors(config *AuthorsConfig) error {
+	if config.Verbose {
+		SetLogLevel(LogLevelDebug)
+	} else if config.Quiet {
+		SetLogLevel(LogLevelSilent)
+	}
+
+	if config.Path == "" {
+		config.Path = "."
+	}
+
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", config.Path)
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is required for the authors command: %v", err)
+	}
+
+	startTime := time.Now()
+
+	walker, err := newWalkerFromConfig(&config.Config)
+	if err != nil {
+		return err
+	}
+	fileStats, errors := walker.Walk()
+
+	// Blame files concurrently
+	authorStats := make(map[string]*AuthorStats)
+	total := &LanguageStats{Language: "Total"}
+	jobs := make(chan *FileStats)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	workers := config.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fs := range jobs {
+				// Blame with the language the walk resolved
+				lang := resolveLanguageName(fs.Language)
+				if lang == nil {
+					continue
+				}
+				stats, err := BlameFile(config.Path, fs.FilePath, lang, config.Since, config.MailmapFile)
+				mu.Lock()
+				if err != nil {
+					LogDebug("Skipping %s: %v", fs.FilePath, err)
+					errors = append(errors, err)
+				} else {
+					mergeAuthorStats(authorStats, stats)
+					total.FileCount++
+					for _, as := range stats {
+						total.CommentLines += as.Stats.CommentLines
+						total.CodeLines += as.Stats.CodeLines
+						total.TotalLines += as.Stats.TotalLines
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, fs := range fileStats {
+		jobs <- fs
+	}
+	close(jobs)
+	wg.Wait()
+	sortErrors(errors)
+
+	elapsed := time.Since(startTime)
+
+	switch config.OutputFormat {
+	case "json":
+		PrintAuthorsJSON(authorStats, total)
+	default:
+		PrintAuthors(authorStats, total, config.OutputFormat == "formatted")
+	}
+
+	if config.ShowErrors && len(errors) > 0 {
+		PrintErrors(errors)
+	}
+
+	if !config.Quiet {
+		fmt.Printf("Time elapsed: %v\n", elapsed.Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// PrintAuthors prints statistics per author with a per-language breakdown
+func PrintAuthors(authorStats map[string]*AuthorStats, total *LanguageStats, formatted bool) {
+	printGroupHeader("Author")
+
+	for _, author := range sortAuthorsByCode(authorStats) {
+		stats := authorStats[author]
+		printDirRow(author, &stats.Stats, formatted)
+		printLanguageRows(stats.Languages, "", formatted)
+	}
+
+	printGroupSeparator()
+	printDirRow("Total", total, formatted)
+	printGroupSeparator()
+	fmt.Println()
+	fmt.Printf("Bus factor: %d\n", BusFactor(authorStats))
+	fmt.Println()
+}
+
+// PrintAuthorsJSON prints statistics per author in JSON format
+func PrintAuthorsJSON(authorStats map[string]*AuthorStats, total *LanguageStats) {
+	fmt.Println("{")
+	fmt.Println("  \"authors\": {")
+
+	authors := sortAuthorsByCode(authorStats)
+	for i, author := range authors {
+		stats := authorStats[author]
+		comma := ","
+		if i == len(authors)-1 {
+			comma = ""
+		}
+
+		fmt.Printf("    %s: {\n", jsonString(author))
+		fmt.Printf("      \"stats\": %s,\n", formatStatsJSON(&stats.Stats))
+		printLanguagesJSON(stats.Languages, "      ")
+		fmt.Printf("    }%s\n", comma)
+	}
+
+	fmt.Println("  },")
+	fmt.Printf("  \"total\": %s,\n", formatStatsJSON(total))
+	fmt.Printf("  \"bus_factor\": %d\n", BusFactor(authorStats))
+	fmt.Println("}")
+}
+
+// parseAuthorsFlags parses the arguments of the authors command
+func parseAuthorsFlags(args []string) (*AuthorsConfig, error) {
+	config := &AuthorsConfig{}
+
+	fs := flag.NewFlagSet("authors", flag.ContinueOnError)
+	registerScanFlags(fs, &config.Config)
+	registerAuthorsFlags(fs, config)
+	fs.Usage = printAuthorsUsage
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		config.Path = fs.Arg(0)
+	}
+
+	if err := applyConfig(fs, &config.Config); err != nil {
+		return nil, err
+	}
+	if config.Archives {
+		return nil, fmt.Errorf("--archives cannot be used with authors: files inside archives have no git history")
+	}
+	switch config.OutputFormat {
+	case "default", "json", "formatted":
+	default:
+		return nil, fmt.Errorf("unsupported format for authors: %s (use default, json or formatted)", config.OutputFormat)
+	}
+
+	return config, nil
+}
+
+// registerAuthorsFlags defines the flags specific to the authors command
+func registerAuthorsFlags(fs *flag.FlagSet, config *AuthorsConfig) {
+	fs.StringVar(&config.Since, "since", "", "Only attribute lines changed since this date (e.g., \"6 months ago\", 2024-01-01)")
+	fs.StringVar(&config.MailmapFile, "mailmap", "", "Mailmap file used to resolve author identities (default: the repository's .mailmap)")
+}
+
+func printAuthorsUsage() {
+	fmt.Printf(`Usage:
+  %s authors [options] [path]
+
+Attributes every code and comment line of a git repository to the author who
+last modified it, using git blame, and aggregates the lines per author and language.
+
+Options:
+  --since <date>          Only attribute lines changed since this date; older lines
+                          are reported as "%s"
+  --mailmap <file>        Mailmap file used to resolve author identities
+                          (default: the repository's .mailmap)
+  --config <file>         Config file to use instead of the .locc.toml or .locc.yaml
+                          found from the path upward
+  -w, --workers <n>       Number of worker goroutines (default: number of CPUs)
+  --dir-workers <n>       Number of directories read concurrently (default: number of CPUs)
+  -H, --hidden            Include hidden files and directories
+  --follow-symlinks       Follow symlinked directories and files
+  --one-file-system       Do not cross filesystem boundaries
+  -f, --format <format>   Output format: default, json, formatted
+  --include <globs>       Comma-separated list of globs relative to the root to count
+  -x, --exclude <globs>   Comma-separated list of directory names or globs relative to the root to exclude
+  --include-regex <re>    Regular expression for paths to count (repeatable)
+  --exclude-regex <re>    Regular expression for paths to exclude (repeatable)
+  --lang <languages>      Comma-separated list of languages to count (e.g., "Go,TypeScript")
+  --exclude-lang <langs>  Comma-separated list of languages to exclude (e.g., "Markdown,JSON")
+  --other                 Count files without a registered language as plain text under "Other"
+  --max-file-size <size>  Skip files larger than this size (e.g., 1MB, 512K)
+  --min-file-size <size>  Skip files smaller than this size (e.g., 10B)
+  --max-depth <n>         Maximum directory depth to scan, 1 for the root only (default: unlimited)
+  -i, --ignore <patterns> Comma-separated list of patterns to exclude files
+  -e, --errors            Show detailed error messages (e.g., untracked files)
+  -q, --quiet             Suppress non-essential output
+
+Examples:
+  %s authors .                       Authorship of the current repository
+  %s authors --since "1 year ago" .  Authorship of lines changed in the last year
+
+`, AppName, beforeSince, AppName, AppName)
+}
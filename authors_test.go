@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const samplePorcelain = `e2a99991a603c1d74808af79f0182665bb20ef74 1 1 2
+author Alice
+author-mail <alice@example.com>
+author-time 1700000000
+author-tz +0000
+summary initial
+filename a.go
+	package a
+e2a99991a603c1d74808af79f0182665bb20ef74 2 2
+author Alice
+author-mail <alice@example.com>
+summary initial
+filename a.go
+	// comment
+0000000000000000000000000000000000000001 3 3 1
+author Bob
+author-mail <bob@example.com>
+boundary
+filename a.go
+	func f() {}
+`
+
+func TestParseBlamePorcelain(t *testing.T) {
+	lines, err := parseBlamePorcelain(strings.NewReader(samplePorcelain))
+	if err != nil {
+		t.Fatalf("parseBlamePorcelain error: %v", err)
+	}
+
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+	if lines[1].Author != "Alice" || lines[1].Email != "alice@example.com" {
+		t.Errorf("line 1 = %+v, want Alice <alice@example.com>", lines[1])
+	}
+	if !lines[3].Boundary || lines[3].Author != "Bob" {
+		t.Errorf("line 3 = %+v, want boundary line by Bob", lines[3])
+	}
+	if authorKey(lines[3]) != beforeSince {
+		t.Errorf("authorKey(boundary) = %q, want %q", authorKey(lines[3]), beforeSince)
+	}
+}
+
+func TestBusFactor(t *testing.T) {
+	authorStats := map[string]*AuthorStats{
+		"a":         {Stats: LanguageStats{CodeLines: 60}},
+		"b":         {Stats: LanguageStats{CodeLines: 30}},
+		"c":         {Stats: LanguageStats{CodeLines: 10}},
+		beforeSince: {Stats: LanguageStats{CodeLines: 1000}},
+	}
+	if got := BusFactor(authorStats); got != 1 {
+		t.Errorf("BusFactor() = %d, want 1", got)
+	}
+
+	authorStats["a"].Stats.CodeLines = 40
+	if got := BusFactor(authorStats); got != 2 {
+		t.Errorf("BusFactor() = %d, want 2", got)
+	}
+}
+
+func TestBlameFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "authors-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	git := func(author string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=" + author, "-c", "user.email=" + strings.ToLower(author) + "@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	file := filepath.Join(tmpDir, "main.go")
+	git("Alice", "init", "-q")
+	os.WriteFile(file, []byte("package main\n\n// Helper\nfunc a() {}\n"), 0644)
+	git("Alice", "add", ".")
+	git("Alice", "commit", "-q", "-m", "initial")
+	os.WriteFile(file, []byte("package main\n\n// Helper\nfunc a() {}\nfunc b() {}\n"), 0644)
+	git("Bob", "commit", "-q", "-a", "-m", "add b")
+
+	authorStats, err := BlameFile(tmpDir, file, GetLanguage(".go"), "", "")
+	if err != nil {
+		t.Fatalf("BlameFile error: %v", err)
+	}
+
+	alice := authorStats["Alice <alice@example.com>"]
+	if alice == nil || alice.Stats.CodeLines != 2 || alice.Stats.CommentLines != 1 {
+		t.Errorf("Alice = %+v, want 2 code and 1 comment line", alice)
+	}
+	bob := authorStats["Bob <bob@example.com>"]
+	if bob == nil || bob.Stats.CodeLines != 1 || bob.Languages["Go"].CodeLines != 1 {
+		t.Errorf("Bob = %+v, want 1 Go code line", bob)
+	}
+}
+
+func TestParseAuthorsFlags(t *testing.T) {
+	config, err := parseAuthorsFlags([]string{"--since", "1 year ago", "-x", "docs", "/repo"})
+	if err != nil {
+		t.Fatalf("parseAuthorsFlags error: %v", err)
+	}
+	if config.Since != "1 year ago" || config.Path != "/repo" || len(config.Excludes) != 1 {
+		t.Errorf("parseAuthorsFlags = %+v", config)
+	}
+
+	if _, err := parseAuthorsFlags([]string{"--archives", "/repo"}); err == nil {
+		t.Error("expected an error for --archives")
+	}
+	for _, format := range []string{"ndjson", "prometheus", "compact"} {
+		if _, err := parseAuthorsFlags([]string{"-f", format, "/repo"}); err == nil || !strings.Contains(err.Error(), "unsupported format for authors") {
+			t.Errorf("-f %s: error = %v, want an unsupported format error", format, err)
+		}
+	}
+}
+
+func TestRunAuthorsOther(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.zzz"), []byte("first\nsecond\n"), 0644)
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	config, err := parseAuthorsFlags([]string{"--other", "-f", "json", "-q", tmpDir})
+	if err != nil {
+		t.Fatalf("parseAuthorsFlags error: %v", err)
+	}
+	output := captureStdout(func() {
+		err = RunAuthors(config)
+	})
+	if err != nil {
+		t.Fatalf("RunAuthors error: %v", err)
+	}
+	// Files counted as Other are blamed like any other file
+	if !strings.Contains(output, `"total": {"files": 2, "blank": 0, "comment": 0, "code": 3`) || !strings.Contains(output, `"Other"`) {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CodeOwnersLocations defines where a CODEOWNERS file is looked up, in
+// GitHub's order of precedence
+var CodeOwnersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// unowned is the name of the bucket for files without an owner
+const unowned = "(unowned)"
+
+// CodeOwnersRule is a single pattern line of a CODEOWNERS file
+type CodeOwnersRule struct {
+	Pattern string
+	Owners  []string
+	Line    int
+	re      *regexp.Regexp
+}
+
+// CodeOwners holds the parsed rules of a CODEOWNERS file
+type CodeOwners struct {
+	Path  string
+	Rules []CodeOwnersRule
+}
+
+// OwnerStats holds aggregated statistics for an owner
+type OwnerStats struct {
+	Owner     string
+	Stats     LanguageStats
+	Languages map[string]*LanguageStats
+}
+
+// LoadCodeOwners finds and parses the CODEOWNERS file for a repository root.
+// It returns nil without an error if no CODEOWNERS file exists.
+func LoadCodeOwners(rootPath string) (*CodeOwners, error) {
+	for _, location := range CodeOwnersLocations {
+		path := filepath.Join(rootPath, filepath.FromSlash(location))
+		file, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		defer file.Close()
+
+		owners, err := ParseCodeOwners(file)
+		if err != nil {
+			return nil, NewFileError(path, err)
+		}
+		owners.Path = path
+		return owners, nil
+	}
+	return nil, nil
+}
+
+// ParseCodeOwners parses CODEOWNERS rules. Lines with patterns GitHub does not
+// support are skipped with a warning, as GitHub ignores them too.
+func ParseCodeOwners(r io.Reader) (*CodeOwners, error) {
+	owners := &CodeOwners{}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Strip trailing comments
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+
+		fields := strings.Fields(line)
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+
+		re, err := compileCodeOwnersPattern(pattern)
+		if err != nil {
+			LogWarn("CODEOWNERS line %d: %v", lineNum, err)
+			continue
+		}
+
+		owners.Rules = append(owners.Rules, CodeOwnersRule{
+			Pattern: pattern,
+			Owners:  fields[1:],
+			Line:    lineNum,
+			re:      re,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
+
+// compileCodeOwnersPattern converts a CODEOWNERS pattern into a regular expression
+// matching slash separated paths relative to the repository root
+func compileCodeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") {
+		return nil, fmt.Errorf("negated pattern %q is not supported", pattern)
+	}
+	if strings.ContainsAny(pattern, "[]") {
+		return nil, fmt.Errorf("character range in pattern %q is not supported", pattern)
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+
+	// Patterns with a slash anywhere but the end are relative to the root
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+
+	expr.WriteString(globToRegexp(trimmed))
+
+	switch {
+	case dirOnly:
+		// Only directories match, so something must follow
+		expr.WriteString("/.*$")
+	case strings.HasSuffix(trimmed, "/*") && !strings.HasSuffix(trimmed, "/**"):
+		// "docs/*" matches files directly in docs but not nested ones
+		expr.WriteString("$")
+	default:
+		// A matching directory owns everything below it
+		expr.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(expr.String())
+}
+
+// Match reports whether the rule matches a slash separated path relative to the root
+func (r *CodeOwnersRule) Match(relPath string) bool {
+	return r.re.MatchString(relPath)
+}
+
+// Owners returns the owners of a path relative to the repository root. The last
+// matching rule wins; nil is returned when no rule matches or the matching rule
+// has no owners.
+func (c *CodeOwners) Owners(relPath string) []string {
+	relPath = filepath.ToSlash(relPath)
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if c.Rules[i].Match(relPath) {
+			if len(c.Rules[i].Owners) == 0 {
+				return nil
+			}
+			return c.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// AggregateOwnerStats aggregates file statistics per owner. Files with several
+// owners are counted for each of them; files without an owner are grouped under
+// the "(unowned)" bucket.
+func AggregateOwnerStats(fileStats []*FileStats, rootPath string, codeOwners *CodeOwners) map[string]*OwnerStats {
+	ownerStats := make(map[string]*OwnerStats)
+
+	for _, fs := range fileStats {
+		if fs == nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(rootPath, fs.FilePath)
+		if err != nil {
+			rel = fs.FilePath
+		}
+
+		owners := codeOwners.Owners(rel)
+		if len(owners) == 0 {
+			owners = []string{unowned}
+		}
+
+		for _, owner := range owners {
+			stats, exists := ownerStats[owner]
+			if !exists {
+				stats = &OwnerStats{
+					Owner:     owner,
+					Stats:     LanguageStats{Language: owner},
+					Languages: make(map[string]*LanguageStats),
+				}
+				ownerStats[owner] = stats
+			}
+			stats.Stats.add(fs)
+			addLanguageStats(stats.Languages, fs)
+		}
+	}
+
+	return ownerStats
+}
+
+// sortOwnersByCode sorts owners by code lines in descending order
+func sortOwnersByCode(ownerStats map[string]*OwnerStats) []string {
+	owners := make([]string, 0, len(ownerStats))
+	for owner := range ownerStats {
+		owners = append(owners, owner)
+	}
+
+	sort.Slice(owners, func(i, j int) bool {
+		a, b := ownerStats[owners[i]], ownerStats[owners[j]]
+		if a.Stats.CodeLines != b.Stats.CodeLines {
+			return a.Stats.CodeLines > b.Stats.CodeLines
+		}
+		return owners[i] < owners[j]
+	})
+
+	return owners
+}
+
+// PrintOwners prints statistics per code owner
+func PrintOwners(ownerStats map[string]*OwnerStats, formatted bool) {
+	printGroupHeader("Owner")
+
+	for _, owner := range sortOwnersByCode(ownerStats) {
+		stats := ownerStats[owner]
+		printDirRow(owner, &stats.Stats, formatted)
+		printLanguageRows(stats.Languages, "", formatted)
+	}
+
+	printGroupSeparator()
+	fmt.Println()
+}
+
+// PrintOwnersJSON prints statistics per code owner in JSON format
+func PrintOwnersJSON(ownerStats map[string]*OwnerStats, total *LanguageStats) {
+	fmt.Println("{")
+	fmt.Println("  \"owners\": {")
+
+	owners := sortOwnersByCode(ownerStats)
+	for i, owner := range owners {
+		stats := ownerStats[owner]
+		comma := ","
+		if i == len(owners)-1 {
+			comma = ""
+		}
+
+		fmt.Printf("    %s: {\n", jsonString(owner))
+		fmt.Printf("      \"stats\": %s,\n", formatStatsJSON(&stats.Stats))
+		printLanguagesJSON(stats.Languages, "      ")
+		fmt.Printf("    }%s\n", comma)
+	}
+
+	fmt.Println("  },")
+	fmt.Printf("  \"total\": %s\n", formatStatsJSON(total))
+	fmt.Println("}")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodeOwnersPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "main.go", true},
+		{"*", "a/b/main.go", true},
+		{"*.js", "web/app.js", true},
+		{"*.js", "web/app.ts", false},
+		{"/build/logs/", "build/logs/out.log", true},
+		{"/build/logs/", "src/build/logs/out.log", false},
+		{"docs/*", "docs/getting-started.md", true},
+		{"docs/*", "docs/build-app/troubleshooting.md", false},
+		{"apps/", "apps/web/main.go", true},
+		{"apps/", "src/apps/web/main.go", true},
+		{"/docs/", "docs/a/b.md", true},
+		{"**/logs", "deep/nested/logs/out.log", true},
+		{"/scripts/**", "scripts/ci/run.sh", true},
+		{"src/**/test", "src/a/b/test/x.go", true},
+		{"src/**/test", "lib/a/test/x.go", false},
+		{"README.md", "sub/README.md", true},
+		{"/README.md", "sub/README.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			re, err := compileCodeOwnersPattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("compileCodeOwnersPattern(%q) error: %v", tt.pattern, err)
+			}
+			rule := CodeOwnersRule{Pattern: tt.pattern, re: re}
+			if got := rule.Match(tt.path); got != tt.want {
+				t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeOwnersLastMatchWins(t *testing.T) {
+	owners, err := ParseCodeOwners(strings.NewReader(`# Default owners
+*       @org/core
+
+*.go    @org/go-team @alice   # Go reviewers
+/vendor/
+!negated @nobody
+`))
+	if err != nil {
+		t.Fatalf("ParseCodeOwners error: %v", err)
+	}
+
+	if len(owners.Rules) != 3 {
+		t.Fatalf("Expected 3 rules (negation skipped), got %d", len(owners.Rules))
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"@org/core"}},
+		{"cmd/main.go", []string{"@org/go-team", "@alice"}},
+		{"vendor/lib/lib.go", nil},
+	}
+	for _, tt := range tests {
+		if got := owners.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCodeOwners(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "codeowners-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	owners, err := LoadCodeOwners(tmpDir)
+	if err != nil || owners != nil {
+		t.Fatalf("LoadCodeOwners on empty dir = %v, %v, want nil, nil", owners, err)
+	}
+
+	// .github/CODEOWNERS takes precedence over the root file
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("* @root\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("* @github\n"), 0644)
+
+	owners, err = LoadCodeOwners(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadCodeOwners error: %v", err)
+	}
+	if got := owners.Owners("main.go"); !reflect.DeepEqual(got, []string{"@github"}) {
+		t.Errorf("Owners(main.go) = %v, want [@github]", got)
+	}
+}
+
+func TestAggregateOwnerStats(t *testing.T) {
+	owners, _ := ParseCodeOwners(strings.NewReader("*.go @go @alice\n/web/ @web\n"))
+	fileStats := []*FileStats{
+		{FilePath: filepath.Join("repo", "main.go"), Language: "Go", CodeLines: 10},
+		{FilePath: filepath.Join("repo", "web", "app.ts"), Language: "TypeScript", CodeLines: 20},
+		{FilePath: filepath.Join("repo", "script.py"), Language: "Python", CodeLines: 5},
+	}
+
+	ownerStats := AggregateOwnerStats(fileStats, "repo", owners)
+
+	want := map[string]int{"@go": 10, "@alice": 10, "@web": 20, unowned: 5}
+	for owner, code := range want {
+		if ownerStats[owner] == nil || ownerStats[owner].Stats.CodeLines != code {
+			t.Errorf("owner %q = %+v, want %d code lines", owner, ownerStats[owner], code)
+		}
+	}
+
+	output := captureStdout(func() {
+		PrintOwners(ownerStats, false)
+	})
+	if !strings.Contains(output, "@web") || !strings.Contains(output, unowned) {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+
+	output = captureStdout(func() {
+		PrintOwnersJSON(ownerStats, &LanguageStats{Language: "Total"})
+	})
+	if !strings.Contains(output, "\"owners\"") || !strings.Contains(output, "\"@alice\"") {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// colDelta is the width of the +/- columns in comparison tables
+	colDelta = 10
+	// colPercent is the width of the percentage column in comparison tables
+	colPercent = 9
+	// colLineDelta is the width of the comment and blank +/- columns
+	colLineDelta = 12
+)
+
+// File statuses in comparisons
+const (
+	StatusAdded     = "added"
+	StatusRemoved   = "removed"
+	StatusModified  = "modified"
+	StatusUnchanged = "unchanged"
+)
+
+// Delta holds the old and new statistics of a language, directory or file
+type Delta struct {
+	Name string
+	Old  LanguageStats
+	New  LanguageStats
+	// OldHash and NewHash hold the content hashes of a file, if the snapshots
+	// recorded them
+	OldHash string
+	NewHash string
+}
+
+// CodeChange returns the difference in code lines
+func (d *Delta) CodeChange() int {
+	return d.New.CodeLines - d.Old.CodeLines
+}
+
+// Changed reports whether any line count differs
+func (d *Delta) Changed() bool {
+	return d.Old.BlankLines != d.New.BlankLines ||
+		d.Old.CommentLines != d.New.CommentLines ||
+		d.Old.CodeLines != d.New.CodeLines ||
+		d.Old.FileCount != d.New.FileCount
+}
+
+// Status returns whether a file was added, removed, modified or is unchanged.
+// A file whose line counts are equal is modified if its content hash differs;
+// snapshots without hashes compare the counts only.
+func (d *Delta) Status() string {
+	switch {
+	case d.Old.FileCount == 0:
+		return StatusAdded
+	case d.New.FileCount == 0:
+		return StatusRemoved
+	case d.Changed():
+		return StatusModified
+	case d.OldHash != "" && d.NewHash != "" && d.OldHash != d.NewHash:
+		return StatusModified
+	}
+	return StatusUnchanged
+}
+
+// Comparison holds the differences between two snapshots
+type Comparison struct {
+	Languages   []*Delta
+	Directories []*Delta
+	// Files holds only the files that were added, removed or modified
+	Files []*Delta
+	Total Delta
+	// Statuses counts the files per status
+	Statuses map[string]int
+}
+
+// CompareConfig holds the configuration of the compare and compare-dirs
+// commands. Baseline is the snapshot, or the old directory for compare-dirs.
+type CompareConfig struct {
+	Config
+	Baseline string
+	Top      int
+}
+
+// CompareSnapshots computes the differences from before to after. Directories are
+// grouped at the given depth below the root; a depth of 0 or less means no limit.
+func CompareSnapshots(before, after *Snapshot, depth int) *Comparison {
+	languages := make(map[string]*Delta)
+	directories := make(map[string]*Delta)
+	files := make(map[string]*Delta)
+
+	add := func(fs *FileStats, side func(d *Delta) *LanguageStats) {
+		for _, group := range []struct {
+			deltas map[string]*Delta
+			name   string
+		}{
+			{languages, fs.Language},
+			{directories, compareDir(fs.FilePath, depth)},
+			{files, fs.FilePath},
+		} {
+			d, exists := group.deltas[group.name]
+			if !exists {
+				d = &Delta{Name: group.name}
+				group.deltas[group.name] = d
+			}
+			side(d).add(fs)
+		}
+	}
+	for _, fs := range before.Files {
+		add(fs, func(d *Delta) *LanguageStats { return &d.Old })
+		files[fs.FilePath].OldHash = fs.Hash
+	}
+	for _, fs := range after.Files {
+		add(fs, func(d *Delta) *LanguageStats { return &d.New })
+		files[fs.FilePath].NewHash = fs.Hash
+	}
+
+	c := &Comparison{
+		Languages:   sortDeltas(languages),
+		Directories: sortDeltas(directories),
+		Total:       Delta{Name: "Total", Old: *before.Total, New: *after.Total},
+		Statuses:    make(map[string]int),
+	}
+	for _, d := range sortDeltas(files) {
+		status := d.Status()
+		c.Statuses[status]++
+		if status != StatusUnchanged {
+			c.Files = append(c.Files, d)
+		}
+	}
+	return c
+}
+
+// compareDir returns the directory a file is grouped under, cut at depth
+func compareDir(relPath string, depth int) string {
+	dir := path.Dir(relPath)
+	if dir == "." {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	trimArchiveSeparators(parts)
+	if depth > 0 && len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// sortDeltas returns the deltas sorted by the size of their code change
+// (descending), then by new code lines and name
+func sortDeltas(deltas map[string]*Delta) []*Delta {
+	sorted := make([]*Delta, 0, len(deltas))
+	for _, d := range deltas {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := abs(sorted[i].CodeChange()), abs(sorted[j].CodeChange())
+		if a != b {
+			return a > b
+		}
+		if sorted[i].New.CodeLines != sorted[j].New.CodeLines {
+			return sorted[i].New.CodeLines > sorted[j].New.CodeLines
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// formatChange formats a difference with an explicit sign
+func formatChange(n int, formatted bool) string {
+	if n == 0 {
+		return "0"
+	}
+	sign := "+"
+	if n < 0 {
+		sign = "-"
+	}
+	if formatted {
+		return sign + FormatNumber(abs(n))
+	}
+	return fmt.Sprintf("%s%d", sign, abs(n))
+}
+
+// formatPercentChange formats the relative change from before to after
+func formatPercentChange(before, after int) string {
+	switch {
+	case before == after:
+		return "0.0%"
+	case before == 0:
+		return "new"
+	case after == 0:
+		return "removed"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(after-before)*100/float64(before))
+}
+
+// PrintComparison prints the language, directory and file tables of a comparison,
+// showing at most top changed files (all if top is 0 or less)
+func PrintComparison(c *Comparison, top int, formatted bool) {
+	printDeltaTable("Language", c.Languages, &c.Total, formatted)
+	printDeltaTable("Directory", c.Directories, &c.Total, formatted)
+
+	files := c.Files
+	if top > 0 && len(files) > top {
+		files = files[:top]
+	}
+	printDeltaTable("File", files, nil, formatted)
+	if len(files) < len(c.Files) {
+		fmt.Printf("  ... and %d more changed files\n", len(c.Files)-len(files))
+	}
+	fmt.Println()
+	fmt.Printf("Files: %d added, %d removed, %d modified, %d unchanged\n",
+		c.Statuses[StatusAdded], c.Statuses[StatusRemoved], c.Statuses[StatusModified], c.Statuses[StatusUnchanged])
+	fmt.Println()
+}
+
+// printDeltaTable prints deltas with the new counts, their change and the
+// percentage change of code lines. Rows of the file table show the file's
+// status instead of file counts.
+func printDeltaTable(title string, deltas []*Delta, total *Delta, formatted bool) {
+	width := colDirectory + colFiles + colDelta + colCode + colDelta + colPercent + colLineDelta + colLineDelta + 7
+	separator := strings.Repeat("-", width)
+
+	fmt.Println()
+	fmt.Println(separator)
+	if title == "File" {
+		fmt.Printf("%-*s %*s", colDirectory, title, colFiles+colDelta+1, "Status")
+	} else {
+		fmt.Printf("%-*s %*s %*s", colDirectory, title, colFiles, "Files", colDelta, "+/-")
+	}
+	fmt.Printf(" %*s %*s %*s %*s %*s\n",
+		colCode, "Code", colDelta, "+/-", colPercent, "%",
+		colLineDelta, "+/- Comment", colLineDelta, "+/- Blank")
+	fmt.Println(separator)
+	for _, d := range deltas {
+		printDeltaRow(d, title == "File", formatted)
+	}
+	if len(deltas) == 0 {
+		fmt.Println("(no changes)")
+	}
+	if total != nil {
+		fmt.Println(separator)
+		printDeltaRow(total, false, formatted)
+	}
+	fmt.Println(separator)
+}
+
+// printDeltaRow prints a single row of a comparison table
+func printDeltaRow(d *Delta, showStatus, formatted bool) {
+	name := d.Name
+	if len(name) > colDirectory {
+		name = "..." + name[len(name)-colDirectory+3:]
+	}
+
+	format := func(n int) string {
+		if formatted {
+			return FormatNumber(n)
+		}
+		return fmt.Sprintf("%d", n)
+	}
+
+	if showStatus {
+		fmt.Printf("%-*s %*s", colDirectory, name, colFiles+colDelta+1, d.Status())
+	} else {
+		fmt.Printf("%-*s %*s %*s", colDirectory, name,
+			colFiles, format(d.New.FileCount), colDelta, formatChange(d.New.FileCount-d.Old.FileCount, formatted))
+	}
+	fmt.Printf(" %*s %*s %*s %*s %*s\n",
+		colCode, format(d.New.CodeLines), colDelta, formatChange(d.CodeChange(), formatted),
+		colPercent, formatPercentChange(d.Old.CodeLines, d.New.CodeLines),
+		colLineDelta, formatChange(d.New.CommentLines-d.Old.CommentLines, formatted),
+		colLineDelta, formatChange(d.New.BlankLines-d.Old.BlankLines, formatted))
+}
+
+// PrintComparisonJSON prints a comparison in JSON format
+func PrintComparisonJSON(c *Comparison) {
+	fmt.Println("{")
+	printDeltasJSON("languages", c.Languages)
+	fmt.Println(",")
+	printDeltasJSON("directories", c.Directories)
+	fmt.Println(",")
+	printDeltasJSON("files", c.Files)
+	fmt.Println(",")
+	fmt.Printf("  \"total\": %s,\n", formatDeltaJSON(&c.Total, ""))
+	fmt.Printf("  \"files_by_status\": {\"added\": %d, \"removed\": %d, \"modified\": %d, \"unchanged\": %d}\n",
+		c.Statuses[StatusAdded], c.Statuses[StatusRemoved], c.Statuses[StatusModified], c.Statuses[StatusUnchanged])
+	fmt.Println("}")
+}
+
+// printDeltasJSON prints deltas as a JSON object keyed by name; file deltas
+// include their status
+func printDeltasJSON(key string, deltas []*Delta) {
+	fmt.Printf("  %s: {", jsonString(key))
+	for i, d := range deltas {
+		status := ""
+		if key == "files" {
+			status = d.Status()
+		}
+		fmt.Printf("\n    %s: %s%s", jsonString(d.Name), formatDeltaJSON(d, status), jsonComma(i, len(deltas)))
+	}
+	if len(deltas) > 0 {
+		fmt.Print("\n  ")
+	}
+	fmt.Print("}")
+}
+
+// formatDeltaJSON formats a delta as a JSON object, with a status if not empty
+func formatDeltaJSON(d *Delta, status string) string {
+	prefix := ""
+	if status != "" {
+		prefix = fmt.Sprintf("\"status\": %s, ", jsonString(status))
+	}
+	return fmt.Sprintf("{%s\"old\": %s, \"new\": %s, \"code_change\": %d, \"comment_change\": %d, \"blank_change\": %d}",
+		prefix, formatStatsJSON(&d.Old), formatStatsJSON(&d.New), d.CodeChange(),
+		d.New.CommentLines-d.Old.CommentLines, d.New.BlankLines-d.Old.BlankLines)
+}
+
+// RunCompare executes the compare command
+func RunCompare(config *CompareConfig) error {
+	if config.Verbose {
+		SetLogLevel(LogLevelDebug)
+	} else if config.Quiet {
+		SetLogLevel(LogLevelSilent)
+	}
+	if config.Path == "" {
+		config.Path = "."
+	}
+
+	baseline, err := LoadSnapshot(config.Baseline)
+	if err != nil {
+		return err
+	}
+
+	// The other side is either a directory to scan or a second snapshot
+	var current *Snapshot
+	var errors []error
+	if info, err := os.Stat(config.Path); err == nil && !info.IsDir() {
+		current, err = LoadSnapshot(config.Path)
+		if err != nil {
+			return err
+		}
+	} else {
+		current, errors, err = TakeSnapshot(&config.Config)
+		if err != nil {
+			return err
+		}
+	}
+
+	comparison := CompareSnapshots(baseline, current, config.DirDepth)
+	switch config.OutputFormat {
+	case "json":
+		PrintComparisonJSON(comparison)
+	default:
+		PrintComparison(comparison, config.Top, config.OutputFormat == "formatted")
+	}
+
+	if config.ShowErrors && len(errors) > 0 {
+		PrintErrors(errors)
+	}
+	return nil
+}
+
+// RunCompareDirs executes the compare-dirs command, scanning both directories
+// with the same options and matching files by their path relative to each root
+func RunCompareDirs(config *CompareConfig) error {
+	if config.Verbose {
+		SetLogLevel(LogLevelDebug)
+	} else if config.Quiet {
+		SetLogLevel(LogLevelSilent)
+	}
+
+	oldConfig := config.Config
+	oldConfig.Path = config.Baseline
+	before, oldErrors, err := TakeSnapshot(&oldConfig)
+	if err != nil {
+		return err
+	}
+	after, newErrors, err := TakeSnapshot(&config.Config)
+	if err != nil {
+		return err
+	}
+
+	comparison := CompareSnapshots(before, after, config.DirDepth)
+	switch config.OutputFormat {
+	case "json":
+		PrintComparisonJSON(comparison)
+	default:
+		PrintComparison(comparison, config.Top, config.OutputFormat == "formatted")
+	}
+
+	errors := append(oldErrors, newErrors...)
+	if config.ShowErrors && len(errors) > 0 {
+		PrintErrors(errors)
+	}
+	return nil
+}
+
+// parseCompareDirsFlags parses the arguments of the compare-dirs command
+func parseCompareDirsFlags(args []string) (*CompareConfig, error) {
+	config := &CompareConfig{}
+
+	fs := flag.NewFlagSet("compare-dirs", flag.ContinueOnError)
+	registerScanFlags(fs, &config.Config)
+	registerCompareFlags(fs, config)
+	fs.Usage = printCompareDirsUsage
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return nil, fmt.Errorf("compare-dirs needs an old and a new directory")
+	}
+	config.Baseline = fs.Arg(0)
+	config.Path = fs.Arg(1)
+
+	if err := applyConfig(fs, &config.Config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// parseCompareFlags parses the arguments of the compare command
+func parseCompareFlags(args []string) (*CompareConfig, error) {
+	config := &CompareConfig{}
+
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	registerScanFlags(fs, &config.Config)
+	registerCompareFlags(fs, config)
+	fs.Usage = printCompareUsage
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	switch fs.NArg() {
+	case 0:
+		fs.Usage()
+		return nil, fmt.Errorf("compare needs a snapshot to compare against")
+	case 1:
+		config.Baseline = fs.Arg(0)
+	default:
+		config.Baseline = fs.Arg(0)
+		config.Path = fs.Arg(1)
+	}
+
+	if err := applyConfig(fs, &config.Config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// registerCompareFlags defines the flags specific to the compare command
+func registerCompareFlags(fs *flag.FlagSet, config *CompareConfig) {
+	fs.IntVar(&config.DirDepth, "depth", 1, "Directory depth changes are grouped at (0 for unlimited)")
+	fs.IntVar(&config.Top, "top", 20, "Maximum number of changed files to list (0 for all)")
+}
+
+func printCompareUsage() {
+	fmt.Printf(`Usage:
+  %s compare [options] <snapshot> [path | snapshot]
+
+Compares a snapshot saved with "%s snapshot" against a directory, or against a
+second snapshot, and prints the changes per language, per directory and per file.
+
+Options:
+  --depth <n>             Directory depth changes are grouped at (default: 1, 0 for unlimited)
+  --top <n>               Maximum number of changed files to list (default: 20, 0 for all)
+  -f, --format <format>   Output format: default, json, formatted
+  --config <file>         Config file to use instead of the .locc.toml or .locc.yaml
+                          found from the path upward
+  -w, --workers <n>       Number of worker goroutines (default: number of CPUs)
+  -H, --hidden            Include hidden files and directories
+  --include <globs>       Comma-separated list of globs relative to the root to count
+  -x, --exclude <globs>   Comma-separated list of directory names or globs relative to the root to exclude
+  -i, --ignore <patterns> Comma-separated list of patterns to exclude files
+  --lang <languages>      Comma-separated list of languages to count (e.g., "Go,TypeScript")
+  --exclude-lang <langs>  Comma-separated list of languages to exclude (e.g., "Markdown,JSON")
+  -e, --errors            Show detailed error messages
+  -q, --quiet             Suppress non-essential output
+
+All scan options of the main command are accepted. Scan the directory with the same
+options as the snapshot, or files counted differently show up as changes.
+
+Examples:
+  %s compare baseline.json .             Changes since the baseline was saved
+  %s compare v1.json v2.json             Changes between two saved snapshots
+  %s compare --depth 2 vendor.json vendor
+                                         Changes of a vendor drop, two levels deep
+
+`, AppName, AppName, AppName, AppName, AppName)
+}
+
+func printCompareDirsUsage() {
+	fmt.Printf(`Usage:
+  %s compare-dirs [options] <old> <new>
+
+Scans two directories with the same options and prints the files added, removed and
+modified, matched by their path relative to each directory, with the changes of code,
+comment and blank lines per language, per directory and per file. Useful for forks and
+upstream merges that do not share git history.
+
+Options:
+  --depth <n>             Directory depth changes are grouped at (default: 1, 0 for unlimited)
+  --top <n>               Maximum number of changed files to list (default: 20, 0 for all)
+  -f, --format <format>   Output format: default, json, formatted
+  --config <file>         Config file to use instead of the .locc.toml or .locc.yaml
+                          found from the new directory upward
+  -w, --workers <n>       Number of worker goroutines (default: number of CPUs)
+  -H, --hidden            Include hidden files and directories
+  --include <globs>       Comma-separated list of globs relative to the root to count
+  -x, --exclude <globs>   Comma-separated list of directory names or globs relative to the root to exclude
+  -i, --ignore <patterns> Comma-separated list of patterns to exclude files
+  --lang <languages>      Comma-separated list of languages to count (e.g., "Go,TypeScript")
+  --exclude-lang <langs>  Comma-separated list of languages to exclude (e.g., "Markdown,JSON")
+  -e, --errors            Show detailed error messages
+  -q, --quiet             Suppress non-essential output
+
+All scan options of the main command are accepted.
+
+Examples:
+  %s compare-dirs upstream/ fork/        What the fork changed
+  %s compare-dirs --top 0 v1.2/ v1.3/    Every file changed between two releases
+
+`, AppName, AppName, AppName)
+}
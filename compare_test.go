@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func snapshotOf(files ...*FileStats) *Snapshot {
+	langStats := AggregateStats(files)
+	return &Snapshot{Languages: langStats, Total: TotalStats(langStats), Files: files}
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	before := snapshotOf(
+		&FileStats{FilePath: "cmd/main.go", Language: "Go", CodeLines: 50, TotalLines: 60},
+		&FileStats{FilePath: "internal/db/db.go", Language: "Go", CodeLines: 200, CommentLines: 20, TotalLines: 240},
+		&FileStats{FilePath: "scripts/gen.py", Language: "Python", CodeLines: 30, TotalLines: 30},
+		&FileStats{FilePath: "README.md", Language: "Markdown", CodeLines: 10, TotalLines: 12},
+	)
+	after := snapshotOf(
+		&FileStats{FilePath: "cmd/main.go", Language: "Go", CodeLines: 50, TotalLines: 60},
+		&FileStats{FilePath: "internal/db/db.go", Language: "Go", CodeLines: 260, CommentLines: 25, TotalLines: 305},
+		&FileStats{FilePath: "internal/db/cache.go", Language: "Go", CodeLines: 40, TotalLines: 45},
+		&FileStats{FilePath: "README.md", Language: "Markdown", CodeLines: 10, TotalLines: 12},
+	)
+
+	c := CompareSnapshots(before, after, 1)
+
+	names := func(deltas []*Delta) []string {
+		var result []string
+		for _, d := range deltas {
+			result = append(result, d.Name)
+		}
+		return result
+	}
+	if got := names(c.Languages); !reflect.DeepEqual(got, []string{"Go", "Python", "Markdown"}) {
+		t.Errorf("languages = %v", got)
+	}
+	if got := names(c.Directories); !reflect.DeepEqual(got, []string{"internal", "scripts", "cmd", "."}) {
+		t.Errorf("directories = %v", got)
+	}
+	if got := names(c.Files); !reflect.DeepEqual(got, []string{"internal/db/db.go", "internal/db/cache.go", "scripts/gen.py"}) {
+		t.Errorf("changed files = %v", got)
+	}
+
+	goDelta := c.Languages[0]
+	if goDelta.Old.CodeLines != 250 || goDelta.New.CodeLines != 350 || goDelta.CodeChange() != 100 || goDelta.New.FileCount != 3 {
+		t.Errorf("unexpected Go delta: %+v", goDelta)
+	}
+	wantStatuses := map[string]int{StatusAdded: 1, StatusRemoved: 1, StatusModified: 1, StatusUnchanged: 2}
+	if !reflect.DeepEqual(c.Statuses, wantStatuses) {
+		t.Errorf("statuses = %v, want %v", c.Statuses, wantStatuses)
+	}
+	for _, d := range c.Files {
+		want := map[string]string{
+			"internal/db/db.go":    StatusModified,
+			"internal/db/cache.go": StatusAdded,
+			"scripts/gen.py":       StatusRemoved,
+		}[d.Name]
+		if d.Status() != want {
+			t.Errorf("%s status = %q, want %q", d.Name, d.Status(), want)
+		}
+	}
+	if c.Total.CodeChange() != 70 {
+		t.Errorf("total code change = %d, want 70", c.Total.CodeChange())
+	}
+
+	// Equal counts are compared by hash when both snapshots recorded one
+	for _, tt := range []struct {
+		oldHash, newHash string
+		want             string
+	}{
+		{"aa", "bb", StatusModified},
+		{"aa", "aa", StatusUnchanged},
+		{"", "bb", StatusUnchanged},
+	} {
+		old := snapshotOf(&FileStats{FilePath: "a.go", Language: "Go", CodeLines: 1, TotalLines: 1, Hash: tt.oldHash})
+		cur := snapshotOf(&FileStats{FilePath: "a.go", Language: "Go", CodeLines: 1, TotalLines: 1, Hash: tt.newHash})
+		if got := CompareSnapshots(old, cur, 1).Statuses; got[tt.want] != 1 {
+			t.Errorf("hashes %q and %q: statuses = %v, want %s", tt.oldHash, tt.newHash, got, tt.want)
+		}
+	}
+
+	if got := names(CompareSnapshots(before, after, 0).Directories); got[0] != "internal/db" {
+		t.Errorf("unlimited depth directories = %v", got)
+	}
+}
+
+func TestFormatChanges(t *testing.T) {
+	tests := []struct {
+		before, after int
+		change        string
+		percent       string
+	}{
+		{100, 150, "+50", "+50.0%"},
+		{200, 150, "-50", "-25.0%"},
+		{10, 10, "0", "0.0%"},
+		{0, 1234, "+1,234", "new"},
+		{1234, 0, "-1,234", "removed"},
+	}
+
+	for _, tt := range tests {
+		if got := formatChange(tt.after-tt.before, true); got != tt.change {
+			t.Errorf("formatChange(%d) = %q, want %q", tt.after-tt.before, got, tt.change)
+		}
+		if got := formatPercentChange(tt.before, tt.after); got != tt.percent {
+			t.Errorf("formatPercentChange(%d, %d) = %q, want %q", tt.before, tt.after, got, tt.percent)
+		}
+	}
+}
+
+func TestPrintComparison(t *testing.T) {
+	before := snapshotOf(&FileStats{FilePath: "a.go", Language: "Go", CodeLines: 10, TotalLines: 10})
+	after := snapshotOf(
+		&FileStats{FilePath: "a.go", Language: "Go", CodeLines: 12, TotalLines: 12},
+		&FileStats{FilePath: "b.go", Language: "Go", CodeLines: 5, TotalLines: 5},
+	)
+
+	output := captureStdout(func() {
+		PrintComparison(CompareSnapshots(before, after, 1), 1, false)
+	})
+	for _, want := range []string{"Language", "Directory", "Status", "+70.0%", "... and 1 more changed files",
+		"Files: 1 added, 0 removed, 1 modified, 0 unchanged"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	output = captureStdout(func() {
+		PrintComparisonJSON(CompareSnapshots(before, after, 1))
+	})
+	if !strings.Contains(output, `"code_change": 7`) || !strings.Contains(output, `"b.go": {"status": "added", "old": {"files": 0`) {
+		t.Errorf("unexpected JSON output:\n%s", output)
+	}
+}
+
+func TestCompareDirs(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(oldDir, "src"), 0755)
+	os.MkdirAll(filepath.Join(newDir, "src"), 0755)
+	os.WriteFile(filepath.Join(oldDir, "src", "a.go"), []byte("package a\n"), 0644)
+	os.WriteFile(filepath.Join(newDir, "src", "a.go"), []byte("package a\n\n// A does nothing\nfunc A() {}\n"), 0644)
+	// Same line counts, different content
+	os.WriteFile(filepath.Join(oldDir, "src", "b.go"), []byte("package b\n"), 0644)
+	os.WriteFile(filepath.Join(newDir, "src", "b.go"), []byte("package c\n"), 0644)
+	os.WriteFile(filepath.Join(oldDir, "old.py"), []byte("x = 1\n"), 0644)
+	os.WriteFile(filepath.Join(newDir, "vendor.go"), []byte("package v\n"), 0644)
+
+	config, err := parseCompareDirsFlags([]string{"-x", "/vendor.go", "-f", "json", oldDir, newDir})
+	if err != nil {
+		t.Fatalf("parseCompareDirsFlags error: %v", err)
+	}
+	output := captureStdout(func() {
+		err = RunCompareDirs(config)
+	})
+	if err != nil {
+		t.Fatalf("RunCompareDirs error: %v", err)
+	}
+
+	for _, want := range []string{
+		`"src/a.go": {"status": "modified"`,
+		`"src/b.go": {"status": "modified"`,
+		`"old.py": {"status": "removed"`,
+		`"code_change": 1, "comment_change": 1, "blank_change": 1`,
+		`"files_by_status": {"added": 0, "removed": 1, "modified": 2, "unchanged": 0}`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "vendor.go") {
+		t.Errorf("excluded file should not be compared:\n%s", output)
+	}
+
+	captureStdout(func() {
+		_, err = parseCompareDirsFlags([]string{oldDir})
+	})
+	if err == nil {
+		t.Error("expected an error with a single directory")
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigFileNames are the names of project config files, looked up in the
+// scanned directory and its parents
+var ConfigFileNames = []string{".locc.toml", ".locc.yaml", ".locc.yml"}
+
+// userConfigNames are the names of the user config file in the user's config
+// directory (e.g., ~/.config/locc/config.toml)
+var userConfigNames = []string{"config.toml", "config.yaml", "config.yml"}
+
+// configEnvPrefix prefixes environment variables setting options, e.g.
+// LOCC_FORMAT=json or LOCC_MAX_FILE_SIZE=1MB
+const configEnvPrefix = "LOCC_"
+
+// commandLineOnly lists long flags that cannot be set from config files or
+// environment variables. The directories and address served by the serve
+// command must not be widened by a config file found in the current directory.
+var commandLineOnly = map[string]bool{
+	"path":           true,
+	"config":         true,
+	"output":         true,
+	"explain":        true,
+	"files-from":     true,
+	"changed-since":  true,
+	"stdin":          true,
+	"stdin-filename": true,
+	"list-languages": true,
+	"addr":           true,
+	"allow":          true,
+}
+
+// configLanguagesKey is the config file table mapping extensions to languages
+const configLanguagesKey = "languages"
+
+// configSetting is the value of an option and where it was set
+type configSetting struct {
+	values []string
+	source string
+}
+
+// LoadConfigFile parses a TOML or YAML config file, chosen by its extension
+func LoadConfigFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		values, err = ParseYAML(string(data))
+	default:
+		values, err = ParseTOML(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return values, nil
+}
+
+// FindConfigFile looks for a project config file in dir and its parents. It
+// returns an empty path without an error if there is none.
+func FindConfigFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		var found []string
+		for _, name := range ConfigFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				found = append(found, path)
+			}
+		}
+		if len(found) > 1 {
+			return "", fmt.Errorf("found several config files in %s: %s", dir, strings.Join(found, ", "))
+		}
+		if len(found) == 1 {
+			return found[0], nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// userConfigFile returns the path of the user config file, or an empty string
+// if there is none
+func userConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	for _, name := range userConfigNames {
+		path := filepath.Join(dir, AppName, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// applyConfig sets the flags that were not given on the command line from, in
+// decreasing order of precedence, LOCC_* environment variables, the project
+// config file and the user config file. The project config file is the one
+// given by --config or LOCC_CONFIG, or else the first one found from the
+// scanned path upward.
+func applyConfig(fs *flag.FlagSet, config *Config) error {
+	known := knownConfigKeys()
+	settings := make(map[string]configSetting)
+
+	var files []string
+	if path := userConfigFile(); path != "" {
+		files = append(files, path)
+	}
+	projectFile := config.ConfigFile
+	if projectFile == "" {
+		projectFile = os.Getenv(configEnvPrefix + "CONFIG")
+	}
+	if projectFile == "" {
+		found, err := FindConfigFile(configSearchDir(config.Path))
+		if err != nil {
+			return err
+		}
+		projectFile = found
+	}
+	if projectFile != "" {
+		files = append(files, projectFile)
+	}
+
+	for _, path := range files {
+		values, err := LoadConfigFile(path)
+		if err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(values) {
+			if key == configGatesKey {
+				gates, err := parseConfigGates(values[key])
+				if err != nil {
+					return fmt.Errorf("%s: %v", path, err)
+				}
+				config.Gates = gates
+				continue
+			}
+			if key == configLanguagesKey {
+				overrides, err := parseConfigLanguages(values[key])
+				if err != nil {
+					return fmt.Errorf("%s: %v", path, err)
+				}
+				config.LangOverrides = overrides
+				continue
+			}
+			if !known[key] {
+				return fmt.Errorf("%s: unknown key %q", path, key)
+			}
+			strs, err := configStrings(values[key])
+			if err != nil {
+				return fmt.Errorf("%s: invalid value for %s: %v", path, key, err)
+			}
+			settings[key] = configSetting{values: strs, source: path}
+		}
+	}
+
+	for key := range known {
+		name := configEnvName(key)
+		if value, ok := os.LookupEnv(name); ok {
+			settings[key] = configSetting{values: []string{value}, source: name}
+		}
+	}
+
+	// Flags given on the command line win, including through their shorthand
+	explicit := make(map[flag.Value]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Value] = true
+	})
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f := fs.Lookup(key)
+		if f == nil || explicit[f.Value] {
+			// Options of other commands are ignored
+			continue
+		}
+		setting := settings[key]
+		if err := setFlagValues(fs, f, setting.values); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %s: %v", setting.source, strings.Join(setting.values, ","), key, err)
+		}
+	}
+	return nil
+}
+
+// setFlagValues sets a flag from config values. Repeatable flags are set once
+// per value, other flags once with the values joined by commas.
+func setFlagValues(fs *flag.FlagSet, f *flag.Flag, values []string) error {
+	if _, ok := f.Value.(*appendFlag); ok {
+		*f.Value.(*appendFlag) = nil
+		for _, value := range values {
+			if err := fs.Set(f.Name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fs.Set(f.Name, strings.Join(values, ","))
+}
+
+// knownConfigKeys returns the long flags of all commands that can be set from
+// config files and environment variables
+func knownConfigKeys() map[string]bool {
+	mainFlags := flag.NewFlagSet(AppName, flag.ContinueOnError)
+	registerScanFlags(mainFlags, &Config{})
+	registerMainFlags(mainFlags, &Config{})
+	authorsFlags := flag.NewFlagSet("authors", flag.ContinueOnError)
+	registerAuthorsFlags(authorsFlags, &AuthorsConfig{})
+	snapshotFlags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	registerSnapshotFlags(snapshotFlags, &SnapshotConfig{})
+	compareFlags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	registerCompareFlags(compareFlags, &CompareConfig{})
+	serveFlags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	registerServeFlags(serveFlags, &ServeConfig{})
+
+	keys := make(map[string]bool)
+	for _, fs := range []*flag.FlagSet{mainFlags, authorsFlags, snapshotFlags, compareFlags, serveFlags} {
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) > 1 && !commandLineOnly[f.Name] {
+				keys[f.Name] = true
+			}
+		})
+	}
+	return keys
+}
+
+// configEnvName returns the environment variable for a flag, e.g.
+// LOCC_MAX_FILE_SIZE for max-file-size
+func configEnvName(key string) string {
+	return configEnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// configSearchDir returns the directory where the config file lookup starts
+func configSearchDir(path string) string {
+	if path == "" {
+		path = "."
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
+}
+
+// parseConfigLanguages parses the languages table of a config file, mapping
+// extensions such as ".tpl" to registered language names or "Other"
+func parseConfigLanguages(value interface{}) (map[string]*Language, error) {
+	table, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a table of extensions", configLanguagesKey)
+	}
+
+	overrides := make(map[string]*Language)
+	for _, ext := range sortedKeys(table) {
+		if !strings.HasPrefix(ext, ".") || len(ext) < 2 || strings.ContainsAny(ext, "/\\") {
+			return nil, fmt.Errorf("%s.%q is not an extension such as \".tpl\"", configLanguagesKey, ext)
+		}
+		name, err := configString(table[ext])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s.%q: %v", configLanguagesKey, ext, err)
+		}
+		lang := resolveLanguageName(name)
+		if lang == nil {
+			return nil, fmt.Errorf("unknown language %q for %s.%q (see --list-languages)", name, configLanguagesKey, ext)
+		}
+		overrides[strings.ToLower(ext)] = lang
+	}
+	return overrides, nil
+}
+
+// configStrings converts a scalar or an array of scalars to flag values
+func configStrings(value interface{}) ([]string, error) {
+	if values, ok := value.([]interface{}); ok {
+		strs := make([]string, 0, len(values))
+		for _, v := range values {
+			s, err := configString(v)
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, s)
+		}
+		return strs, nil
+	}
+
+	s, err := configString(value)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// configString converts a scalar to a flag value
+func configString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", fmt.Errorf("missing value")
+	default:
+		return "", fmt.Errorf("expected a string, number, boolean or list")
+	}
+}
+
+// sortedKeys returns the keys of a config table in sorted order
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
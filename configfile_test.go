@@ -0,0 +1,213 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseWithConfig parses main command arguments against a fresh flag set and
+// applies config files and environment variables
+func parseWithConfig(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	config := &Config{}
+	fs := flag.NewFlagSet(AppName, flag.ContinueOnError)
+	registerScanFlags(fs, config)
+	registerMainFlags(fs, config)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse(%v) error: %v", args, err)
+	}
+	if fs.NArg() > 0 {
+		config.Path = fs.Arg(0)
+	}
+	return config, applyConfig(fs, config)
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	userDir := filepath.Join(tmpDir, "home")
+	repoDir := filepath.Join(tmpDir, "repo")
+	os.MkdirAll(filepath.Join(userDir, AppName), 0755)
+	os.MkdirAll(filepath.Join(repoDir, "src", "pkg"), 0755)
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv("HOME", userDir)
+
+	os.WriteFile(filepath.Join(userDir, AppName, "config.toml"), []byte(`
+format = "formatted"
+workers = 3
+hidden = true
+`), 0644)
+	os.WriteFile(filepath.Join(repoDir, ".locc.yaml"), []byte(`
+format: compact
+exclude: [vendor, docs]
+exclude-regex:
+  - \.pb\.go$
+  - _gen\.go$
+max-file-size: 1MB
+tests: true
+since: 1 year ago
+`), 0644)
+
+	// Config files are found from the scanned path upward, repo over user
+	config, err := parseWithConfig(t, filepath.Join(repoDir, "src", "pkg"))
+	if err != nil {
+		t.Fatalf("applyConfig error: %v", err)
+	}
+	if config.OutputFormat != "compact" || config.Workers != 3 || !config.IncludeHidden || !config.TestSplit {
+		t.Errorf("unexpected config: format=%q workers=%d hidden=%v tests=%v",
+			config.OutputFormat, config.Workers, config.IncludeHidden, config.TestSplit)
+	}
+	if !reflect.DeepEqual(config.Excludes, []string{"vendor", "docs"}) {
+		t.Errorf("Excludes = %v", config.Excludes)
+	}
+	if !reflect.DeepEqual(config.ExcludeRegex, []string{`\.pb\.go$`, `_gen\.go$`}) {
+		t.Errorf("ExcludeRegex = %v", config.ExcludeRegex)
+	}
+	if config.MaxFileSize != 1<<20 {
+		t.Errorf("MaxFileSize = %d", config.MaxFileSize)
+	}
+
+	// Environment variables override config files
+	t.Setenv("LOCC_FORMAT", "json")
+	t.Setenv("LOCC_MAX_FILE_SIZE", "2K")
+	config, err = parseWithConfig(t, repoDir)
+	if err != nil {
+		t.Fatalf("applyConfig error: %v", err)
+	}
+	if config.OutputFormat != "json" || config.MaxFileSize != 2048 {
+		t.Errorf("env not applied: format=%q max-file-size=%d", config.OutputFormat, config.MaxFileSize)
+	}
+
+	// Flags override everything, including through their shorthand
+	config, err = parseWithConfig(t, "-f", "default", "-x", "build", "--exclude-regex", "x", repoDir)
+	if err != nil {
+		t.Fatalf("applyConfig error: %v", err)
+	}
+	if config.OutputFormat != "default" {
+		t.Errorf("OutputFormat = %q, want the flag value", config.OutputFormat)
+	}
+	if !reflect.DeepEqual(config.Excludes, []string{"build"}) || !reflect.DeepEqual(config.ExcludeRegex, []string{"x"}) {
+		t.Errorf("flags merged with config: excludes=%v regex=%v", config.Excludes, config.ExcludeRegex)
+	}
+}
+
+func TestApplyConfigExplicitFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", tmpDir)
+	os.WriteFile(filepath.Join(tmpDir, ".locc.toml"), []byte(`format = "compact"`), 0644)
+	custom := filepath.Join(tmpDir, "ci.toml")
+	os.WriteFile(custom, []byte(`format = "json"`), 0644)
+
+	config, err := parseWithConfig(t, "--config", custom, tmpDir)
+	if err != nil {
+		t.Fatalf("applyConfig error: %v", err)
+	}
+	if config.OutputFormat != "json" {
+		t.Errorf("OutputFormat = %q, want the --config value", config.OutputFormat)
+	}
+
+	t.Setenv("LOCC_CONFIG", custom)
+	config, err = parseWithConfig(t, tmpDir)
+	if err != nil {
+		t.Fatalf("applyConfig error: %v", err)
+	}
+	if config.OutputFormat != "json" {
+		t.Errorf("OutputFormat = %q, want the LOCC_CONFIG value", config.OutputFormat)
+	}
+}
+
+func TestApplyConfigLanguages(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", tmpDir)
+	os.WriteFile(filepath.Join(tmpDir, ".locc.toml"), []byte("[languages]\n\".TPL\" = \"html\"\n\".inc\" = \"PHP\"\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "page.tpl"), []byte("<p>\n<!-- note -->\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "page.inc"), []byte("<?php\n"), 0644)
+
+	config, err := parseWithConfig(t, tmpDir)
+	if err != nil {
+		t.Fatalf("applyConfig error: %v", err)
+	}
+	if lang := config.LangOverrides[".tpl"]; lang == nil || lang.Name != "HTML" {
+		t.Fatalf("LangOverrides = %v, want .tpl mapped to HTML", config.LangOverrides)
+	}
+
+	walker, err := newWalkerFromConfig(config)
+	if err != nil {
+		t.Fatalf("newWalkerFromConfig error: %v", err)
+	}
+	stats, _ := walker.Walk()
+	languages := make(map[string]string)
+	for _, s := range stats {
+		languages[filepath.Base(s.FilePath)] = s.Language
+	}
+	if languages["page.tpl"] != "HTML" || languages["page.inc"] != "PHP" {
+		t.Errorf("counted languages %v, want the config overrides", languages)
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"Unknown key", ".locc.toml", "colour = true", `unknown key "colour"`},
+		{"Command line only", ".locc.toml", `path = "src"`, `unknown key "path"`},
+		{"Serve roots", ".locc.yaml", "allow: /", `unknown key "allow"`},
+		{"Shorthand", ".locc.yaml", "x: vendor", `unknown key "x"`},
+		{"Table", ".locc.toml", "[hidden]\nvalue = true", "invalid value for hidden"},
+		{"Invalid value", ".locc.toml", `workers = "many"`, `invalid value "many" for workers`},
+		{"Syntax", ".locc.yaml", "format json", `line 1: expected "key: value"`},
+		{"Language override key", ".locc.toml", "[languages]\ntpl = \"HTML\"", `languages."tpl" is not an extension`},
+		{"Language override value", ".locc.yaml", "languages:\n  .tpl: Klingon", `unknown language "Klingon"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", tmpDir)
+			t.Setenv("HOME", tmpDir)
+			os.WriteFile(filepath.Join(tmpDir, tt.file), []byte(tt.content), 0644)
+
+			_, err := parseWithConfig(t, tmpDir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("applyConfig error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindConfigFileConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, ".locc.toml"), nil, 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".locc.yaml"), nil, 0644)
+
+	if _, err := FindConfigFile(tmpDir); err == nil {
+		t.Error("expected an error for several config files in one directory")
+	}
+}
+
+func TestParseAuthorsFlagsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", tmpDir)
+	os.WriteFile(filepath.Join(tmpDir, ".locc.toml"), []byte(`
+since = "6 months ago"
+by-dir = true
+`), 0644)
+
+	// Options of the main command are valid in the config but ignored here
+	config, err := parseAuthorsFlags([]string{tmpDir})
+	if err != nil {
+		t.Fatalf("parseAuthorsFlags error: %v", err)
+	}
+	if config.Since != "6 months ago" || config.ByDir {
+		t.Errorf("unexpected config: since=%q by-dir=%v", config.Since, config.ByDir)
+	}
+}
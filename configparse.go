@@ -0,0 +1,748 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Config files are parsed into nested maps holding string, int64, float64,
+// bool, []interface{} and map[string]interface{} values. Only the subsets of
+// TOML 1.0 and YAML 1.2 needed for configuration are supported; documents
+// using anything else are rejected rather than read differently from the specs.
+
+// tomlParser parses TOML tables, dotted and quoted keys, basic and literal
+// strings, integers, floats, booleans, arrays and inline tables
+type tomlParser struct {
+	src     string
+	pos     int
+	line    int
+	defined map[string]bool
+}
+
+// ParseTOML parses a TOML document
+func ParseTOML(src string) (map[string]interface{}, error) {
+	p := &tomlParser{src: src, line: 1, defined: make(map[string]bool)}
+	root := make(map[string]interface{})
+	current := root
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			if strings.HasPrefix(p.src[p.pos:], "[[") {
+				return nil, p.errorf("arrays of tables are not supported")
+			}
+			p.pos++
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.eof() || p.peek() != ']' {
+				return nil, p.errorf("expected ] after table name")
+			}
+			p.pos++
+
+			name := strings.Join(keys, "\x00")
+			if p.defined[name] {
+				return nil, p.errorf("table [%s] is defined twice", strings.Join(keys, "."))
+			}
+			p.defined[name] = true
+			if current, err = p.table(root, keys); err != nil {
+				return nil, err
+			}
+		} else {
+			if err := p.parseKeyValue(current); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine consumes trailing whitespace and an optional comment up to the
+// end of the line
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+	if !p.eof() && p.peek() == '\r' {
+		p.pos++
+	}
+	if p.eof() {
+		return nil
+	}
+	if p.peek() != '\n' {
+		return p.errorf("unexpected %q after value", p.peek())
+	}
+	return nil
+}
+
+// parseKey parses a dotted key such as a, "a.b" or a.'b c'
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("expected a key")
+		}
+
+		var key string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("invalid key character %q", p.peek())
+			}
+			key = p.src[start:p.pos]
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseKeyValue parses key = value into table
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.eof() || p.peek() != '=' {
+		return p.errorf("expected = after key %q", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.table(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	key := keys[len(keys)-1]
+	if _, ok := parent[key]; ok {
+		return p.errorf("key %q is defined twice", strings.Join(keys, "."))
+	}
+	parent[key] = value
+	return nil
+}
+
+// table returns the nested table at keys, creating missing tables
+func (p *tomlParser) table(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	table := root
+	for _, key := range keys {
+		switch v := table[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			table[key] = next
+			table = next
+		case map[string]interface{}:
+			table = v
+		default:
+			return nil, p.errorf("key %q is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// parseValue parses a string, number, boolean, array or inline table
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch p.peek() {
+	case '"', '\'':
+		return p.parseString()
+	case '[':
+		return p.parseArray()
+	case '{':
+		return p.parseInlineTable()
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	token := p.src[start:p.pos]
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	value, err := parseTOMLNumber(token)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return value, nil
+}
+
+// parseTOMLNumber parses an integer or float
+func parseTOMLNumber(token string) (interface{}, error) {
+	number := strings.ReplaceAll(token, "_", "")
+	switch {
+	case tomlDecimal.MatchString(token):
+		return parseConfigInt(number, 10, token)
+	case tomlHex.MatchString(token):
+		return parseConfigInt(number[2:], 16, token)
+	case tomlOctal.MatchString(token):
+		return parseConfigInt(number[2:], 8, token)
+	case tomlBinary.MatchString(token):
+		return parseConfigInt(number[2:], 2, token)
+	case tomlFloat.MatchString(token):
+		return strconv.ParseFloat(number, 64)
+	case tomlSpecialFloat.MatchString(token):
+		if strings.HasSuffix(token, "nan") {
+			return math.NaN(), nil
+		}
+		if token[0] == '-' {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case tomlDateTime.MatchString(token):
+		return nil, fmt.Errorf("dates and times are not supported")
+	}
+	return nil, fmt.Errorf("invalid value %q", token)
+}
+
+// TOML number syntax, where "_" may only separate digits
+var (
+	tomlDecimal      = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	tomlHex          = regexp.MustCompile(`^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$`)
+	tomlOctal        = regexp.MustCompile(`^0o[0-7](_?[0-7])*$`)
+	tomlBinary       = regexp.MustCompile(`^0b[01](_?[01])*$`)
+	tomlFloat        = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*([eE][+-]?[0-9](_?[0-9])*)?|[eE][+-]?[0-9](_?[0-9])*)$`)
+	tomlSpecialFloat = regexp.MustCompile(`^[+-]?(inf|nan)$`)
+	tomlDateTime     = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}|[0-9]{2}:[0-9]{2})`)
+)
+
+// parseConfigInt parses the digits of an integer in the given base, reporting
+// token in errors
+func parseConfigInt(digits string, base int, token string) (int64, error) {
+	n, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("integer %s is out of range", token)
+	}
+	return n, nil
+}
+
+// parseString parses a single-line basic ("...") or literal ('...') string
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.peek()
+	if strings.HasPrefix(p.src[p.pos:], strings.Repeat(string(quote), 3)) {
+		return "", p.errorf("multi-line strings are not supported")
+	}
+
+	start := p.pos
+	p.pos++
+	for !p.eof() && p.peek() != quote && p.peek() != '\n' {
+		if quote == '"' && p.peek() == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.eof() || p.peek() != quote {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+
+	raw := p.src[start:p.pos]
+	if quote == '\'' {
+		return raw[1 : len(raw)-1], nil
+	}
+	s, err := unescapeString(raw[1:len(raw)-1], tomlEscapes)
+	if err != nil {
+		return "", p.errorf("invalid string %s: %v", raw, err)
+	}
+	return s, nil
+}
+
+// tomlEscapes are the escape sequences of TOML basic strings, mapped to their
+// value, or to the number of hex digits that follow
+var tomlEscapes = map[byte]interface{}{
+	'b': "\b", 't': "\t", 'n': "\n", 'f': "\f", 'r': "\r", '"': `"`, '\\': `\`,
+	'u': 4, 'U': 8,
+}
+
+// yamlEscapes are the escape sequences of YAML double-quoted scalars, in the
+// same form as tomlEscapes
+var yamlEscapes = map[byte]interface{}{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+	'r': "\r", 'e': "\x1b", ' ': " ", '"': `"`, '/': "/", '\\': `\`,
+	'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+	'x': 2, 'u': 4, 'U': 8,
+}
+
+// unescapeString decodes the escape sequences of a double-quoted string,
+// rejecting any sequence not in escapes
+func unescapeString(s string, escapes map[byte]interface{}) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			return "", fmt.Errorf("unterminated escape sequence")
+		}
+		i++
+		switch escape := escapes[s[i]].(type) {
+		case string:
+			b.WriteString(escape)
+		case int:
+			if i+escape >= len(s) {
+				return "", fmt.Errorf("short escape sequence \\%s", s[i:])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+escape], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid escape sequence \\%s", s[i:i+1+escape])
+			}
+			b.WriteRune(rune(code))
+			i += escape
+		default:
+			return "", fmt.Errorf("invalid escape sequence \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// parseArray parses an array, which may span several lines
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++
+	values := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+// parseInlineTable parses a single-line { key = value, ... } table
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++
+	table := make(map[string]interface{})
+	p.skipSpace()
+	if !p.eof() && p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated inline table")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// yamlLine is a non-blank line of a YAML document with comments removed
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// ParseYAML parses a YAML document made of block mappings, block sequences of
+// scalars, flow sequences and plain, single- or double-quoted scalars
+func ParseYAML(src string) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(src, "\n") {
+		text := strings.TrimRight(stripYAMLComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || text == "---" || text == "..." {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	if lines[0].indent != 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[0].num)
+	}
+
+	p := &yamlParser{lines: lines}
+	root, err := p.parseMapping(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.pos].num)
+	}
+	return root, nil
+}
+
+// yamlParser parses block nodes from preprocessed lines
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseNode parses the block starting at the current line
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// parseMapping parses key: value lines at the given indentation
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	mapping := make(map[string]interface{})
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		if isYAMLSequenceItem(line.text) {
+			return nil, fmt.Errorf("line %d: unexpected sequence item", line.num)
+		}
+
+		key, rest, err := splitYAMLKey(line)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := mapping[key]; ok {
+			return nil, fmt.Errorf("line %d: key %q is defined twice", line.num, key)
+		}
+		p.pos++
+
+		var value interface{}
+		switch {
+		case rest != "":
+			value, err = parseYAMLScalar(rest, line.num)
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			value, err = p.parseNode(p.lines[p.pos].indent)
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text):
+			// Sequences may be indented at the same level as their key
+			value, err = p.parseSequence(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = value
+	}
+
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return mapping, nil
+}
+
+// parseSequence parses "- item" lines at the given indentation
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	values := []interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if item == "" {
+			return nil, fmt.Errorf("line %d: nested sequence items are not supported", line.num)
+		}
+		if _, _, err := splitYAMLKey(yamlLine{num: line.num, text: item}); err == nil && !isYAMLQuoted(item) {
+			return nil, fmt.Errorf("line %d: mappings in sequences are not supported", line.num)
+		}
+
+		value, err := parseYAMLScalar(item, line.num)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.pos++
+	}
+	return values, nil
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isYAMLQuoted(text string) bool {
+	return strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'")
+}
+
+// splitYAMLKey splits "key: value" into its key and the rest of the line
+func splitYAMLKey(line yamlLine) (string, string, error) {
+	text := line.text
+	if isYAMLQuoted(text) {
+		end := closingQuote(text)
+		if end < 0 {
+			return "", "", fmt.Errorf("line %d: unterminated string", line.num)
+		}
+		key, err := parseYAMLScalar(text[:end+1], line.num)
+		if err != nil {
+			return "", "", err
+		}
+		rest := text[end+1:]
+		if rest != ":" && !strings.HasPrefix(rest, ": ") {
+			return "", "", fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		return fmt.Sprint(key), strings.TrimSpace(rest[1:]), nil
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), nil
+		}
+	}
+	return "", "", fmt.Errorf("line %d: expected \"key: value\"", line.num)
+}
+
+// closingQuote returns the index of the quote closing the string at the start
+// of text, or -1
+func closingQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseYAMLScalar parses a quoted or plain scalar, or a flow sequence
+func parseYAMLScalar(text string, num int) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, "["):
+		return parseYAMLFlowSequence(text, num)
+	case strings.HasPrefix(text, "{"):
+		return nil, fmt.Errorf("line %d: flow mappings are not supported", num)
+	case strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+		return nil, fmt.Errorf("line %d: block scalars are not supported", num)
+	case isYAMLQuoted(text):
+		end := closingQuote(text)
+		if end != len(text)-1 {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s", num, text)
+		}
+		if text[0] == '\'' {
+			return strings.ReplaceAll(text[1:end], "''", "'"), nil
+		}
+		s, err := unescapeString(text[1:end], yamlEscapes)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s: %v", num, text, err)
+		}
+		return s, nil
+	case strings.ContainsAny(text[:1], "&*!%@`"):
+		return nil, fmt.Errorf("line %d: anchors, aliases, tags and reserved indicators are not supported", num)
+	}
+
+	// Plain scalars are resolved with the YAML 1.2 core schema
+	switch text {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	case "y", "Y", "yes", "Yes", "YES", "n", "N", "no", "No", "NO", "on", "On", "ON", "off", "Off", "OFF":
+		// YAML 1.1 read these as booleans, so either reading would surprise someone
+		return nil, fmt.Errorf("line %d: ambiguous value %q, use true or false, or quote it", num, text)
+	}
+	var n int64
+	var err error
+	switch {
+	case yamlDecimal.MatchString(text):
+		n, err = parseConfigInt(text, 10, text)
+	case yamlOctal.MatchString(text):
+		n, err = parseConfigInt(text[2:], 8, text)
+	case yamlHex.MatchString(text):
+		n, err = parseConfigInt(text[2:], 16, text)
+	case yamlFloat.MatchString(text):
+		return strconv.ParseFloat(text, 64)
+	case yamlInf.MatchString(text):
+		if text[0] == '-' {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case yamlNaN.MatchString(text):
+		return math.NaN(), nil
+	default:
+		return text, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", num, err)
+	}
+	return n, nil
+}
+
+// YAML 1.2 core schema number syntax
+var (
+	yamlDecimal = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlOctal   = regexp.MustCompile(`^0o[0-7]+$`)
+	yamlHex     = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	yamlFloat   = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlInf     = regexp.MustCompile(`^[-+]?\.(inf|Inf|INF)$`)
+	yamlNaN     = regexp.MustCompile(`^\.(nan|NaN|NAN)$`)
+)
+
+// parseYAMLFlowSequence parses a single-line [a, "b", 3] sequence
+func parseYAMLFlowSequence(text string, num int) ([]interface{}, error) {
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("line %d: unterminated flow sequence", num)
+	}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	values := []interface{}{}
+	for inner != "" {
+		item := inner
+		if isYAMLQuoted(inner) {
+			end := closingQuote(inner)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", num)
+			}
+			item = inner[:end+1]
+		} else if i := strings.IndexByte(inner, ','); i >= 0 {
+			item = inner[:i]
+		}
+		inner = strings.TrimSpace(inner[len(item):])
+		if strings.HasPrefix(inner, ",") {
+			inner = strings.TrimSpace(inner[1:])
+		} else if inner != "" {
+			return nil, fmt.Errorf("line %d: expected , in flow sequence", num)
+		}
+
+		value, err := parseYAMLScalar(strings.TrimSpace(item), num)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// stripYAMLComment removes a # comment that starts the line or follows a
+// space outside of quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' || c == '\'' && quote == '\'' && i+1 < len(line) && line[i+1] == '\'' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[,:-", rune(line[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
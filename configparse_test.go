@@ -0,0 +1,254 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	got, err := ParseTOML(`# Project settings
+format = "json"          # inline comment
+workers = 4
+ratio = 0.25
+hidden = true
+exclude = [
+  "vendor",
+  'third_party\gen',   # literal string
+]
+"quoted key" = "a # not a comment"
+empty = []
+
+[gates]
+total.max = 1_000
+
+[gates."src/**"]
+limits = { lines = 500, strict = false }
+`)
+	if err != nil {
+		t.Fatalf("ParseTOML error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"format":     "json",
+		"workers":    int64(4),
+		"ratio":      0.25,
+		"hidden":     true,
+		"exclude":    []interface{}{"vendor", `third_party\gen`},
+		"quoted key": "a # not a comment",
+		"empty":      []interface{}{},
+		"gates": map[string]interface{}{
+			"total": map[string]interface{}{"max": int64(1000)},
+			"src/**": map[string]interface{}{
+				"limits": map[string]interface{}{"lines": int64(500), "strict": false},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTOML =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"a = 1\na = 2", "line 2: key \"a\" is defined twice"},
+		{"[t]\n[t]", "line 2: table [t] is defined twice"},
+		{"a = \"unterminated", "line 1: unterminated string"},
+		{"a = [1, 2", "line 1: unterminated array"},
+		{"a = 1 2", "line 1: unexpected '2' after value"},
+		{"a = yes", "line 1: invalid value \"yes\""},
+		{"[[servers]]", "arrays of tables are not supported"},
+		{"a = \"\"\"x\"\"\"", "multi-line strings are not supported"},
+		{"= 1", "invalid key character '='"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseTOML(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseTOML(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	got, err := ParseYAML(`---
+# Project settings
+format: json   # inline comment
+workers: 4
+hidden: true
+exclude:
+  - vendor
+  - "third_party/gen"
+ignore: ['*.log', "*.tmp"]
+test-patterns:
+- e2e/**
+pattern: 'it''s # here'
+gates:
+  "src/**":
+    max-file-lines: 500
+  docs/:
+    min-comment-ratio: 0.1
+`)
+	if err != nil {
+		t.Fatalf("ParseYAML error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"format":        "json",
+		"workers":       int64(4),
+		"hidden":        true,
+		"exclude":       []interface{}{"vendor", "third_party/gen"},
+		"ignore":        []interface{}{"*.log", "*.tmp"},
+		"test-patterns": []interface{}{"e2e/**"},
+		"pattern":       "it's # here",
+		"gates": map[string]interface{}{
+			"src/**": map[string]interface{}{"max-file-lines": int64(500)},
+			"docs/":  map[string]interface{}{"min-comment-ratio": 0.1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseYAML =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"a: 1\na: 2", "line 2: key \"a\" is defined twice"},
+		{"a: 1\n  b: 2", "line 2: unexpected indentation"},
+		{"just a string", "line 1: expected \"key: value\""},
+		{"a:\n  - b: 1", "line 2: mappings in sequences are not supported"},
+		{"a: |\n  text", "line 1: block scalars are not supported"},
+		{"a: {b: 1}", "line 1: flow mappings are not supported"},
+		{"a: [1, 2", "line 1: unterminated flow sequence"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseYAML(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseYAML(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestParseTOMLValues(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    interface{}
+		wantErr string
+	}{
+		{`"tab\there"`, "tab\there", ""},
+		{`"quote \" and \\"`, `quote " and \`, ""},
+		{`"\u00e9\U0001F600"`, "é😀", ""},
+		{`'C:\no\escapes'`, `C:\no\escapes`, ""},
+		{`"\x41"`, nil, `invalid escape sequence \x`},
+		{`"\e"`, nil, `invalid escape sequence \e`},
+		{`"\'"`, nil, `invalid escape sequence \'`},
+		{`"\uD800"`, nil, `invalid escape sequence \uD800`},
+		{`"\u12"`, nil, `short escape sequence`},
+		{"+42", int64(42), ""},
+		{"-17", int64(-17), ""},
+		{"0", int64(0), ""},
+		{"1_000_000", int64(1000000), ""},
+		{"0xDEAD_beef", int64(0xdeadbeef), ""},
+		{"0o755", int64(0755), ""},
+		{"0b1101", int64(13), ""},
+		{"017", nil, `invalid value "017"`},
+		{"1__0", nil, `invalid value "1__0"`},
+		{"_1", nil, `invalid value "_1"`},
+		{"1_", nil, `invalid value "1_"`},
+		{"0X1F", nil, `invalid value "0X1F"`},
+		{"9223372036854775808", nil, "out of range"},
+		{"3.14", 3.14, ""},
+		{"-0.5e-3", -0.0005, ""},
+		{"6e2", 600.0, ""},
+		{"1_0.2_5", 10.25, ""},
+		{"-inf", math.Inf(-1), ""},
+		{".5", nil, `invalid value ".5"`},
+		{"5.", nil, `invalid value "5."`},
+		{"Infinity", nil, `invalid value "Infinity"`},
+		{"0x1p-2", nil, `invalid value "0x1p-2"`},
+		{"True", nil, `invalid value "True"`},
+		{"1979-05-27", nil, "dates and times are not supported"},
+		{"07:32:00", nil, "dates and times are not supported"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTOML("a = " + tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.value, err)
+		} else if !reflect.DeepEqual(got["a"], tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.value, got["a"], tt.want)
+		}
+	}
+
+	if got, err := ParseTOML("a = nan"); err != nil || !math.IsNaN(got["a"].(float64)) {
+		t.Errorf("nan = %v, %v; want NaN", got["a"], err)
+	}
+}
+
+func TestParseYAMLValues(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    interface{}
+		wantErr string
+	}{
+		{"plain text", "plain text", ""},
+		{`"tab\tand\x41\u00e9"`, "tab\tandAé", ""},
+		{`"slash\/ and \e"`, "slash/ and \x1b", ""},
+		{`"\q"`, nil, `invalid escape sequence \q`},
+		{`"\'"`, nil, `invalid escape sequence \'`},
+		{`'single \n'`, `single \n`, ""},
+		{"true", true, ""},
+		{"False", false, ""},
+		{"TRUE", true, ""},
+		{"tRUE", "tRUE", ""},
+		{"yes", nil, `ambiguous value "yes"`},
+		{"No", nil, `ambiguous value "No"`},
+		{"on", nil, `ambiguous value "on"`},
+		{"OFF", nil, `ambiguous value "OFF"`},
+		{"~", nil, ""},
+		{"Null", nil, ""},
+		{"42", int64(42), ""},
+		{"-7", int64(-7), ""},
+		{"0o17", int64(15), ""},
+		{"0x1F", int64(31), ""},
+		{"99999999999999999999", nil, "out of range"},
+		{"1.5", 1.5, ""},
+		{".5", 0.5, ""},
+		{"1e3", 1000.0, ""},
+		{"-.inf", math.Inf(-1), ""},
+		{"inf", "inf", ""},
+		{"1_000", "1_000", ""},
+		{"&anchor value", nil, "anchors, aliases, tags"},
+		{"*alias", nil, "anchors, aliases, tags"},
+		{"!!str 1", nil, "anchors, aliases, tags"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseYAML("a: " + tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.value, err)
+		} else if !reflect.DeepEqual(got["a"], tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.value, got["a"], tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"strings"
 )
@@ -15,6 +16,9 @@ type FileStats struct {
 	CommentLines int
 	CodeLines    int
 	TotalLines   int
+	IsTest       bool
+	// Hash is the hex SHA-256 of the content, if the walker was asked for it
+	Hash string
 }
 
 // LanguageStats holds aggregated statistics for a language
@@ -33,21 +37,52 @@ type CountResult struct {
 	Error error
 }
 
+// LineKind is the category a line is classified as
+type LineKind int
+
+const (
+	// LineBlank is an empty or whitespace-only line
+	LineBlank LineKind = iota
+	// LineComment is a line containing only comments
+	LineComment
+	// LineCode is a line containing code
+	LineCode
+)
+
 // CountLines counts the lines in a file and categorizes them
 func CountLines(filePath string, lang *Language) (*FileStats, error) {
+	return ClassifyLines(filePath, lang, nil)
+}
+
+// ClassifyLines counts the lines in a file like CountLines and additionally
+// calls onLine, if non-nil, with the 1-based number and kind of every line
+func ClassifyLines(filePath string, lang *Language, onLine func(lineNum int, kind LineKind)) (*FileStats, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
+	return ClassifyReader(file, filePath, lang, onLine)
+}
+
+// CountReader counts the lines read from r and categorizes them, reporting
+// them under the given file path
+func CountReader(r io.Reader, filePath string, lang *Language) (*FileStats, error) {
+	return ClassifyReader(r, filePath, lang, nil)
+}
+
+// ClassifyReader counts the lines read from r like CountReader and
+// additionally calls onLine, if non-nil, with the 1-based number and kind of
+// every line
+func ClassifyReader(r io.Reader, filePath string, lang *Language, onLine func(lineNum int, kind LineKind)) (*FileStats, error) {
 	stats := &FileStats{
 		FilePath:  filePath,
 		Language:  lang.Name,
 		Extension: "",
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
 
@@ -154,14 +189,21 @@ func CountLines(filePath string, lang *Language) (*FileStats, error) {
 			i++
 		}
 
+		kind := LineBlank
 		if lineHasCode {
 			stats.CodeLines++
+			kind = LineCode
 		} else if lineHasComment {
 			stats.CommentLines++
+			kind = LineComment
 		} else if stats.TotalLines > 0 { // Should always be true here
 			// Check if it's truly blank or just whitespace
 			stats.BlankLines++
 		}
+
+		if onLine != nil {
+			onLine(stats.TotalLines, kind)
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -183,12 +225,17 @@ func CountLinesGeneric(filePath string) (*FileStats, error) {
 	}
 	defer file.Close()
 
+	return CountReaderGeneric(file, filePath)
+}
+
+// CountReaderGeneric counts the lines read from r like CountLinesGeneric
+func CountReaderGeneric(r io.Reader, filePath string) (*FileStats, error) {
 	stats := &FileStats{
 		FilePath: filePath,
 		Language: "Unknown",
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
 
@@ -227,11 +274,7 @@ func AggregateStats(fileStats []*FileStats) map[string]*LanguageStats {
 			}
 		}
 
-		langStats[lang].FileCount++
-		langStats[lang].BlankLines += fs.BlankLines
-		langStats[lang].CommentLines += fs.CommentLines
-		langStats[lang].CodeLines += fs.CodeLines
-		langStats[lang].TotalLines += fs.TotalLines
+		langStats[lang].add(fs)
 	}
 
 	return langStats
@@ -244,12 +287,26 @@ func TotalStats(langStats map[string]*LanguageStats) *LanguageStats {
 	}
 
 	for _, ls := range langStats {
-		total.FileCount += ls.FileCount
-		total.BlankLines += ls.BlankLines
-		total.CommentLines += ls.CommentLines
-		total.CodeLines += ls.CodeLines
-		total.TotalLines += ls.TotalLines
+		total.merge(ls)
 	}
 
 	return total
 }
+
+// add accumulates a single file's statistics
+func (ls *LanguageStats) add(fs *FileStats) {
+	ls.FileCount++
+	ls.BlankLines += fs.BlankLines
+	ls.CommentLines += fs.CommentLines
+	ls.CodeLines += fs.CodeLines
+	ls.TotalLines += fs.TotalLines
+}
+
+// merge accumulates another set of aggregated statistics
+func (ls *LanguageStats) merge(other *LanguageStats) {
+	ls.FileCount += other.FileCount
+	ls.BlankLines += other.BlankLines
+	ls.CommentLines += other.CommentLines
+	ls.CodeLines += other.CodeLines
+	ls.TotalLines += other.TotalLines
+}
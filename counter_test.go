@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -233,6 +234,22 @@ line 4
 	}
 }
 
+func TestCountReader(t *testing.T) {
+	content := "package main\n\n/* block\n   comment */\nfunc main() {} // trailing\n"
+	stats, err := CountReader(strings.NewReader(content), "(stdin)", Languages[".go"])
+	if err != nil {
+		t.Fatalf("CountReader failed: %v", err)
+	}
+
+	if stats.FilePath != "(stdin)" || stats.Language != "Go" {
+		t.Errorf("got path %q and language %q, want (stdin) and Go", stats.FilePath, stats.Language)
+	}
+	if stats.CodeLines != 2 || stats.CommentLines != 2 || stats.BlankLines != 1 || stats.TotalLines != 5 {
+		t.Errorf("got code=%d comment=%d blank=%d total=%d, want 2, 2, 1, 5",
+			stats.CodeLines, stats.CommentLines, stats.BlankLines, stats.TotalLines)
+	}
+}
+
 func TestAggregateStats(t *testing.T) {
 	fileStats := []*FileStats{
 		{Language: "Go", BlankLines: 10, CommentLines: 5, CodeLines: 100, TotalLines: 115},
@@ -333,3 +350,38 @@ func TestAggregateStatsWithNil(t *testing.T) {
 		t.Errorf("Go FileCount = %d, want 2", goStats.FileCount)
 	}
 }
+
+func TestClassifyLines(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "locc-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "main.go")
+	os.WriteFile(path, []byte("package main\n\n// comment\nfunc main() {}\n"), 0644)
+
+	var kinds []LineKind
+	stats, err := ClassifyLines(path, GetLanguage(".go"), func(lineNum int, kind LineKind) {
+		if lineNum != len(kinds)+1 {
+			t.Errorf("line number = %d, want %d", lineNum, len(kinds)+1)
+		}
+		kinds = append(kinds, kind)
+	})
+	if err != nil {
+		t.Fatalf("ClassifyLines error: %v", err)
+	}
+
+	want := []LineKind{LineCode, LineBlank, LineComment, LineCode}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("line %d kind = %v, want %v", i+1, kinds[i], want[i])
+		}
+	}
+	if stats.CodeLines != 2 || stats.CommentLines != 1 || stats.BlankLines != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// colDirectory is the width of the directory column in tree tables
+	colDirectory = 40
+)
+
+// DirStats holds aggregated statistics for a directory and its descendants
+type DirStats struct {
+	Name      string
+	Path      string
+	Stats     LanguageStats
+	Languages map[string]*LanguageStats
+	Children  map[string]*DirStats
+}
+
+// newDirStats creates an empty DirStats node
+func newDirStats(name, path string) *DirStats {
+	return &DirStats{
+		Name:      name,
+		Path:      path,
+		Stats:     LanguageStats{Language: name},
+		Languages: make(map[string]*LanguageStats),
+		Children:  make(map[string]*DirStats),
+	}
+}
+
+// add accumulates a file's statistics into the directory and its language breakdown
+func (d *DirStats) add(fs *FileStats) {
+	d.Stats.add(fs)
+	addLanguageStats(d.Languages, fs)
+}
+
+// addLanguageStats accumulates a file's statistics into a per-language breakdown
+func addLanguageStats(langStats map[string]*LanguageStats, fs *FileStats) {
+	if _, exists := langStats[fs.Language]; !exists {
+		langStats[fs.Language] = &LanguageStats{Language: fs.Language}
+	}
+	langStats[fs.Language].add(fs)
+}
+
+// AggregateDirStats aggregates file statistics into a directory tree rooted at rootPath.
+// Files nested deeper than maxDepth directories are attributed to their ancestor at
+// that depth. A maxDepth of 0 or less means no limit.
+func AggregateDirStats(fileStats []*FileStats, rootPath string, maxDepth int) *DirStats {
+	root := newDirStats(".", ".")
+
+	for _, fs := range fileStats {
+		if fs == nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(rootPath, fs.FilePath)
+		if err != nil {
+			rel = fs.FilePath
+		}
+		dirs := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+		if len(dirs) == 1 && dirs[0] == "." {
+			dirs = nil
+		}
+		trimArchiveSeparators(dirs)
+		if maxDepth > 0 && len(dirs) > maxDepth {
+			dirs = dirs[:maxDepth]
+		}
+
+		node := root
+		node.add(fs)
+		for i, dir := range dirs {
+			child, exists := node.Children[dir]
+			if !exists {
+				child = newDirStats(dir, strings.Join(dirs[:i+1], "/"))
+				node.Children[dir] = child
+			}
+			child.add(fs)
+			node = child
+		}
+	}
+
+	return root
+}
+
+// sortedChildren returns the directory's children sorted by code lines (descending)
+func (d *DirStats) sortedChildren() []*DirStats {
+	children := make([]*DirStats, 0, len(d.Children))
+	for _, child := range d.Children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].Stats.CodeLines != children[j].Stats.CodeLines {
+			return children[i].Stats.CodeLines > children[j].Stats.CodeLines
+		}
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// PrintDirTree prints the directory tree with per-directory language breakdown
+func PrintDirTree(root *DirStats, formatted bool) {
+	printGroupHeader("Directory")
+	printDirNode(root, "", "", formatted)
+	printGroupSeparator()
+	fmt.Println()
+}
+
+// printGroupHeader prints the header of a table grouped by directory, project or owner
+func printGroupHeader(title string) {
+	fmt.Println()
+	printGroupSeparator()
+	fmt.Printf("%-*s %*s %*s %*s %*s %*s\n",
+		colDirectory, title,
+		colFiles, "Files",
+		colBlank, "Blank",
+		colComment, "Comment",
+		colCode, "Code",
+		colTotal, "Total")
+	printGroupSeparator()
+}
+
+// printGroupSeparator prints a separator line for grouped tables
+func printGroupSeparator() {
+	totalWidth := colDirectory + colFiles + colBlank + colComment + colCode + colTotal + 5
+	fmt.Println(strings.Repeat("-", totalWidth))
+}
+
+// printLanguageRows prints the per-language breakdown rows of a group
+func printLanguageRows(langStats map[string]*LanguageStats, prefix string, formatted bool) {
+	for _, lang := range sortLanguagesByCode(langStats) {
+		printDirRow(prefix+"  · "+lang, langStats[lang], formatted)
+	}
+}
+
+// printDirNode prints a directory row, its languages and its children recursively
+func printDirNode(node *DirStats, prefix, childPrefix string, formatted bool) {
+	printDirRow(prefix+node.Name, &node.Stats, formatted)
+	printLanguageRows(node.Languages, childPrefix, formatted)
+
+	children := node.sortedChildren()
+	for i, child := range children {
+		if i == len(children)-1 {
+			printDirNode(child, childPrefix+"└── ", childPrefix+"    ", formatted)
+		} else {
+			printDirNode(child, childPrefix+"├── ", childPrefix+"│   ", formatted)
+		}
+	}
+}
+
+// printDirRow prints a single row of the directory table
+func printDirRow(name string, stats *LanguageStats, formatted bool) {
+	// Truncate name if too long, counting runes so tree characters are not split
+	runes := []rune(name)
+	if len(runes) > colDirectory {
+		name = string(runes[:colDirectory-3]) + "..."
+	}
+	// Pad by rune count since tree characters are multi-byte
+	name += strings.Repeat(" ", colDirectory-len([]rune(name)))
+
+	format := func(n int) string {
+		if formatted {
+			return FormatNumber(n)
+		}
+		return fmt.Sprintf("%d", n)
+	}
+
+	fmt.Printf("%s %*s %*s %*s %*s %*s\n",
+		name,
+		colFiles, format(stats.FileCount),
+		colBlank, format(stats.BlankLines),
+		colComment, format(stats.CommentLines),
+		colCode, format(stats.CodeLines),
+		colTotal, format(stats.TotalLines))
+}
+
+// PrintDirTreeJSON prints the directory tree as nested JSON objects
+func PrintDirTreeJSON(root *DirStats) {
+	printDirNodeJSON(root, "", "")
+	fmt.Println()
+}
+
+// printDirNodeJSON prints a directory as a JSON object with nested children
+func printDirNodeJSON(node *DirStats, indent, suffix string) {
+	fmt.Println("{")
+	fmt.Printf("%s  \"path\": %s,\n", indent, jsonString(node.Path))
+	fmt.Printf("%s  \"stats\": %s,\n", indent, formatStatsJSON(&node.Stats))
+	fmt.Printf("%s  \"languages\": {", indent)
+
+	langs := sortLanguagesByCode(node.Languages)
+	for i, lang := range langs {
+		comma := ","
+		if i == len(langs)-1 {
+			comma = ""
+		}
+		fmt.Printf("\n%s    %s: %s%s", indent, jsonString(lang), formatStatsJSON(node.Languages[lang]), comma)
+	}
+	if len(langs) > 0 {
+		fmt.Printf("\n%s  ", indent)
+	}
+	fmt.Println("},")
+
+	fmt.Printf("%s  \"children\": {", indent)
+	children := node.sortedChildren()
+	for i, child := range children {
+		comma := ","
+		if i == len(children)-1 {
+			comma = ""
+		}
+		fmt.Printf("\n%s    %s: ", indent, jsonString(child.Name))
+		printDirNodeJSON(child, indent+"    ", comma)
+	}
+	if len(children) > 0 {
+		fmt.Printf("\n%s  ", indent)
+	}
+	fmt.Println("}")
+	fmt.Printf("%s}%s", indent, suffix)
+}
+
+// printLanguagesJSON prints a "languages" object with a per-language breakdown
+func printLanguagesJSON(langStats map[string]*LanguageStats, indent string) {
+	fmt.Printf("%s\"languages\": {\n", indent)
+	langs := sortLanguagesByCode(langStats)
+	for i, lang := range langs {
+		comma := ","
+		if i == len(langs)-1 {
+			comma = ""
+		}
+		fmt.Printf("%s  %s: %s%s\n", indent, jsonString(lang), formatStatsJSON(langStats[lang]), comma)
+	}
+	fmt.Printf("%s}\n", indent)
+}
+
+// jsonString returns s encoded as a JSON string literal
+func jsonString(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "\"\""
+	}
+	return string(b)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggregateDirStats(t *testing.T) {
+	root := filepath.Join("repo")
+	fileStats := []*FileStats{
+		{FilePath: filepath.Join(root, "main.go"), Language: "Go", CodeLines: 10},
+		{FilePath: filepath.Join(root, "svc", "api", "handler.go"), Language: "Go", CodeLines: 20},
+		{FilePath: filepath.Join(root, "svc", "api", "deep", "util.go"), Language: "Go", CodeLines: 5},
+		{FilePath: filepath.Join(root, "web", "app.ts"), Language: "TypeScript", CodeLines: 30},
+	}
+
+	t.Run("Unlimited depth", func(t *testing.T) {
+		tree := AggregateDirStats(fileStats, root, 0)
+
+		if tree.Stats.CodeLines != 65 || tree.Stats.FileCount != 4 {
+			t.Errorf("root stats = %+v, want 65 code lines in 4 files", tree.Stats)
+		}
+		if tree.Languages["Go"].CodeLines != 35 {
+			t.Errorf("root Go code = %d, want 35", tree.Languages["Go"].CodeLines)
+		}
+
+		deep := tree.Children["svc"].Children["api"].Children["deep"]
+		if deep == nil || deep.Path != "svc/api/deep" || deep.Stats.CodeLines != 5 {
+			t.Errorf("svc/api/deep = %+v, want path svc/api/deep with 5 code lines", deep)
+		}
+	})
+
+	t.Run("Depth limit", func(t *testing.T) {
+		tree := AggregateDirStats(fileStats, root, 1)
+
+		svc := tree.Children["svc"]
+		if svc == nil || svc.Stats.CodeLines != 25 {
+			t.Fatalf("svc = %+v, want 25 code lines", svc)
+		}
+		if len(svc.Children) != 0 {
+			t.Errorf("svc should have no children at depth 1, got %d", len(svc.Children))
+		}
+	})
+}
+
+func TestPrintDirTree(t *testing.T) {
+	tree := AggregateDirStats([]*FileStats{
+		{FilePath: filepath.Join("repo", "a", "x.go"), Language: "Go", CodeLines: 1000},
+		{FilePath: filepath.Join("repo", "b", "y.py"), Language: "Python", CodeLines: 2},
+	}, "repo", 0)
+
+	output := captureStdout(func() {
+		PrintDirTree(tree, true)
+	})
+	if !strings.Contains(output, "├── a") || !strings.Contains(output, "└── b") || !strings.Contains(output, "1,002") {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+
+	output = captureStdout(func() {
+		PrintDirTreeJSON(tree)
+	})
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("PrintDirTreeJSON produced invalid JSON: %v\n%s", err, output)
+	}
+	children := decoded["children"].(map[string]interface{})
+	if _, ok := children["a"]; !ok {
+		t.Errorf("JSON missing child directory a: %s", output)
+	}
+}
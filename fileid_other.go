@@ -0,0 +1,17 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// fileID identifies a file by its device and inode numbers
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// getFileID returns the identity of a file. Device and inode numbers are not
+// available on this platform, so symlink loops are only detected by the walk depth.
+func getFileID(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
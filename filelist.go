@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ReadFileList reads a list of paths separated by NUL bytes, as written by
+// "git diff --name-only -z", or else by newlines. Empty entries are dropped.
+func ReadFileList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sep := "\n"
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = "\x00"
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(data), sep) {
+		if sep == "\n" {
+			path = strings.TrimRight(path, "\r")
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// LoadFileList reads a list of paths from a file, or from standard input if
+// path is "-"
+func LoadFileList(path string) ([]string, error) {
+	if path == "-" {
+		return ReadFileList(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadFileList(file)
+}
+
+// GitChangedFiles lists the files below dir that differ from rev in the
+// working tree, including staged and untracked files but not deleted ones.
+// Paths are relative to dir.
+func GitChangedFiles(dir, rev string) ([]string, error) {
+	changed, err := gitFileList(dir, "diff", "--name-only", "-z", "--relative", "--diff-filter=d", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitFileList(dir, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, path := range append(changed, untracked...) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// gitFileList runs a git command in dir that prints NUL separated paths
+func gitFileList(dir string, args ...string) ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return ReadFileList(bytes.NewReader(out))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"newlines", "a.go\nsrc/b.go\n", []string{"a.go", "src/b.go"}},
+		{"crlf", "a.go\r\nb.go\r\n", []string{"a.go", "b.go"}},
+		{"nul", "a b.go\x00c\nd.go\x00", []string{"a b.go", "c\nd.go"}},
+		{"empty entries", "\na.go\n\n", []string{"a.go"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFileList(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFileList(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkerSetFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.go":                    "package a\n",
+		"src/b.go":                "package b\n\nfunc B() {}\n",
+		"src/c.go":                "package c\n",
+		"lib/d.py":                "x = 1\n",
+		"node_modules/m/index.js": "var m = 1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetFiles([]string{
+		"a.go",
+		"src",
+		"src/b.go",
+		filepath.Join(tmpDir, "lib", "d.py"),
+		"node_modules/m/index.js",
+		"missing.go",
+		"../outside.go",
+	})
+	results, errs := walker.Walk()
+
+	var got []string
+	for _, stats := range results {
+		rel, _ := filepath.Rel(tmpDir, stats.FilePath)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{"a.go", "lib/d.py", "src/b.go", "src/c.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("counted %q, want %q", got, want)
+	}
+	if len(errs) != 1 {
+		t.Errorf("got %d errors, want 1 for the outside path: %v", len(errs), errs)
+	}
+	if n := walker.GetSkipStats().Count(SkipMissing); n != 1 {
+		t.Errorf("missing skips = %d, want 1", n)
+	}
+	if n := walker.GetSkipStats().Count(SkipExcludedDir); n != 1 {
+		t.Errorf("excluded directory skips = %d, want 1", n)
+	}
+}
+
+func TestGitChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	write("a.go", "package a\n")
+	write("sub/b.go", "package b\n")
+	write("old.go", "package old\n")
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	write("a.go", "package a\n\nfunc A() {}\n")
+	write("sub/c.go", "package c\n")
+	write("sub/staged.go", "package staged\n")
+	git("add", "sub/staged.go")
+	os.Remove(filepath.Join(tmpDir, "old.go"))
+
+	got, err := GitChangedFiles(tmpDir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.go", "sub/c.go", "sub/staged.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GitChangedFiles = %q, want %q", got, want)
+	}
+
+	// Paths are relative to a subdirectory and limited to it
+	got, err = GitChangedFiles(filepath.Join(tmpDir, "sub"), "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"c.go", "staged.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GitChangedFiles in sub = %q, want %q", got, want)
+	}
+
+	if _, err := GitChangedFiles(tmpDir, "no-such-rev"); err == nil {
+		t.Error("expected an error for an unknown revision")
+	}
+}
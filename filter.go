@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// PathFilter decides which files are counted based on include and exclude
+// patterns matched against slash separated paths relative to the walk root.
+//
+// Globs follow .gitignore conventions: a glob without a slash matches a file or
+// directory name at any depth, a glob with a slash is anchored at the root, "*"
+// and "?" do not match "/", "**" matches across directories and a trailing "/"
+// matches directories only. An exclude that is a plain name, without a slash or
+// wildcard, also matches directories only, as -x has always done. A glob
+// matching a directory applies to everything below it. Regular expressions are
+// matched against file paths only.
+//
+// Excludes take precedence over includes: a file is counted if no exclude
+// matches it and, when includes are given, at least one include matches it.
+type PathFilter struct {
+	includes     []*pathGlob
+	excludes     []*pathGlob
+	includeRegex []*regexp.Regexp
+	excludeRegex []*regexp.Regexp
+}
+
+// pathGlob is a compiled glob pattern
+type pathGlob struct {
+	pattern string
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// NewPathFilter creates a filter that accepts every path
+func NewPathFilter() *PathFilter {
+	return &PathFilter{}
+}
+
+// AddInclude adds a glob that files must match to be counted
+func (f *PathFilter) AddInclude(pattern string) error {
+	g, err := compilePathGlob(pattern)
+	if err != nil {
+		return err
+	}
+	f.includes = append(f.includes, g)
+	return nil
+}
+
+// AddExclude adds a glob excluding matching files and directories, or only
+// directories if it is a plain name
+func (f *PathFilter) AddExclude(pattern string) error {
+	g, err := compilePathGlob(pattern)
+	if err != nil {
+		return err
+	}
+	if !strings.ContainsAny(pattern, "/*?[\\") {
+		g.dirOnly = true
+	}
+	f.excludes = append(f.excludes, g)
+	return nil
+}
+
+// AddIncludeRegex adds a regular expression that files must match to be counted
+func (f *PathFilter) AddIncludeRegex(expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid include regex %q: %v", expr, err)
+	}
+	f.includeRegex = append(f.includeRegex, re)
+	return nil
+}
+
+// AddExcludeRegex adds a regular expression excluding matching files
+func (f *PathFilter) AddExcludeRegex(expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid exclude regex %q: %v", expr, err)
+	}
+	f.excludeRegex = append(f.excludeRegex, re)
+	return nil
+}
+
+// ExcludesDir reports whether a directory and everything below it is excluded
+func (f *PathFilter) ExcludesDir(relPath string) bool {
+	return f.DirReason(relPath) != ""
+}
+
+// DirReason describes why a directory is excluded, or returns an empty string
+// if it is not
+func (f *PathFilter) DirReason(relPath string) string {
+	for _, g := range f.excludes {
+		if g.re.MatchString(relPath) {
+			return fmt.Sprintf("matches exclude glob %q", g.pattern)
+		}
+	}
+	return ""
+}
+
+// Match reports whether a file is counted
+func (f *PathFilter) Match(relPath string) bool {
+	return f.Reason(relPath) == ""
+}
+
+// Reason describes why a file is not counted, or returns an empty string if it is
+func (f *PathFilter) Reason(relPath string) string {
+	if g := matchGlobs(f.excludes, relPath); g != nil {
+		return fmt.Sprintf("matches exclude glob %q", g.pattern)
+	}
+	if re := matchRegexps(f.excludeRegex, relPath); re != nil {
+		return fmt.Sprintf("matches exclude regex %q", re.String())
+	}
+	if len(f.includes) == 0 && len(f.includeRegex) == 0 {
+		return ""
+	}
+	if matchGlobs(f.includes, relPath) != nil || matchRegexps(f.includeRegex, relPath) != nil {
+		return ""
+	}
+	return "matches no include glob or regex"
+}
+
+// matchGlobs returns the first glob matching the file or one of its parent
+// directories, or nil if none matches
+func matchGlobs(globs []*pathGlob, relPath string) *pathGlob {
+	for _, g := range globs {
+		if !g.dirOnly && g.re.MatchString(relPath) {
+			return g
+		}
+		for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if g.re.MatchString(dir) {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// matchRegexps returns the first regular expression matching the path, or nil
+// if none matches
+func matchRegexps(res []*regexp.Regexp, relPath string) *regexp.Regexp {
+	for _, re := range res {
+		if re.MatchString(relPath) {
+			return re
+		}
+	}
+	return nil
+}
+
+// compilePathGlob compiles a glob into a regular expression matching whole paths
+func compilePathGlob(pattern string) (*pathGlob, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid glob %q", pattern)
+	}
+
+	// Globs with a slash anywhere but the end are relative to the root
+	prefix := "^(?:.*/)?"
+	if strings.Contains(trimmed, "/") {
+		prefix = "^"
+	}
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	re, err := regexp.Compile(prefix + globToRegexp(trimmed) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %v", pattern, err)
+	}
+	return &pathGlob{pattern: pattern, dirOnly: dirOnly, re: re}, nil
+}
+
+// globToRegexp translates glob syntax into an unanchored regular expression:
+// "**/" matches zero or more directories, "**" anything, "*" and "?" anything
+// but "/", "[...]" a character class and "\" escapes the next character
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			expr.WriteString("[^/]*")
+		case glob[i] == '?':
+			expr.WriteString("[^/]")
+		case glob[i] == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case glob[i] == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		}
+	}
+	return expr.String()
+}
+
+// LanguageFilter decides which languages are counted. Excluded languages take
+// precedence over included ones; without includes every language is counted.
+type LanguageFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// NewLanguageFilter creates a filter from language names, matched
+// case-insensitively against the registry and "Other". Unknown names are an error.
+func NewLanguageFilter(include, exclude []string) (*LanguageFilter, error) {
+	includeSet, err := languageSet(include)
+	if err != nil {
+		return nil, err
+	}
+	excludeSet, err := languageSet(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &LanguageFilter{include: includeSet, exclude: excludeSet}, nil
+}
+
+// languageSet resolves language names to their registered spelling
+func languageSet(names []string) (map[string]bool, error) {
+	set := make(map[string]bool)
+	for _, name := range names {
+		lang := resolveLanguageName(name)
+		if lang == nil {
+			return nil, fmt.Errorf("unknown language %q (see --list-languages)", name)
+		}
+		set[lang.Name] = true
+	}
+	return set, nil
+}
+
+// Match reports whether files of the named language are counted
+func (f *LanguageFilter) Match(name string) bool {
+	if f.exclude[name] {
+		return false
+	}
+	return len(f.include) == 0 || f.include[name]
+}
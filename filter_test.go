@@ -0,0 +1,284 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPathFilterGlobs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/locc/main.go", true},
+		{"*.go", "main.go.txt", false},
+		{"vendor", "vendor/lib/a.go", true},
+		{"vendor", "src/vendor/lib/a.go", true},
+		{"vendor/", "vendor", false},
+		{"vendor/", "vendor/a.go", true},
+		{"internal/legacy/**", "internal/legacy/a/b.go", true},
+		{"internal/legacy/**", "pkg/internal/legacy/a.go", false},
+		{"src/*/generated/*.ts", "src/web/generated/api.ts", true},
+		{"src/*/generated/*.ts", "src/web/app/generated/api.ts", false},
+		{"src/**/generated/*.ts", "src/web/app/generated/api.ts", true},
+		{"src/**/generated/*.ts", "src/generated/api.ts", true},
+		{"/main.go", "cmd/main.go", false},
+		{"/main.go", "main.go", true},
+		{"file?.go", "file1.go", true},
+		{"file?.go", "file10.go", false},
+		{"file[0-9].go", "file7.go", true},
+		{"file[!0-9].go", "file7.go", false},
+		{"file[!0-9].go", "filex.go", true},
+		{`\*.go`, "*.go", true},
+		{`\*.go`, "a.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			filter := NewPathFilter()
+			if err := filter.AddInclude(tt.pattern); err != nil {
+				t.Fatalf("AddInclude(%q) error: %v", tt.pattern, err)
+			}
+			if got := filter.Match(tt.path); got != tt.want {
+				t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathFilterPrecedence(t *testing.T) {
+	filter := NewPathFilter()
+	filter.AddInclude("src/**")
+	filter.AddIncludeRegex(`^tools/.*\.go$`)
+	filter.AddExclude("src/gen")
+	filter.AddExcludeRegex(`_test\.go$`)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/main.go", true},
+		{"src/gen/api.go", false},
+		{"src/main_test.go", false},
+		{"tools/build.go", true},
+		{"tools/build_test.go", false},
+		{"tools/README.md", false},
+		{"docs/index.md", false},
+	}
+
+	for _, tt := range tests {
+		if got := filter.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if !filter.ExcludesDir("src/gen") {
+		t.Error("expected src/gen to be excluded")
+	}
+	if filter.ExcludesDir("src") {
+		t.Error("expected src not to be excluded")
+	}
+}
+
+func TestPathFilterNoPatterns(t *testing.T) {
+	filter := NewPathFilter()
+	if !filter.Match("any/path.go") {
+		t.Error("expected an empty filter to match every path")
+	}
+	if filter.ExcludesDir("any") {
+		t.Error("expected an empty filter not to exclude directories")
+	}
+}
+
+func TestPathFilterExcludeName(t *testing.T) {
+	filter := NewPathFilter()
+	filter.AddExclude("build")
+	filter.AddExclude("*.min.js")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"build", true},
+		{"scripts/build", true},
+		{"build/out.js", false},
+		{"src/build/gen.go", false},
+		{"app.min.js", false},
+		{"app.js", true},
+	}
+	for _, tt := range tests {
+		if got := filter.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if !filter.ExcludesDir("src/build") {
+		t.Error("expected directory src/build to be excluded")
+	}
+}
+
+func TestPathFilterInvalid(t *testing.T) {
+	filter := NewPathFilter()
+	if err := filter.AddIncludeRegex("("); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+	if err := filter.AddExclude("/"); err == nil {
+		t.Error("expected an error for an empty glob")
+	}
+	if err := filter.AddExclude("a[]"); err == nil {
+		t.Error("expected an error for an empty character class")
+	}
+}
+
+func TestWalkerPathFilter(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range []string{
+		"main.go",
+		"internal/core/core.go",
+		"internal/legacy/old.go",
+		"internal/legacy/deep/older.go",
+		"src/web/generated/api.ts",
+		"src/web/app.ts",
+	} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("x\n"), 0644)
+	}
+
+	filter := NewPathFilter()
+	filter.AddInclude("internal/**")
+	filter.AddInclude("src/**")
+	filter.AddExclude("internal/legacy/**")
+	filter.AddExclude("src/*/generated/*.ts")
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetPathFilter(filter)
+	stats, errs := walker.Walk()
+	if len(errs) > 0 {
+		t.Fatalf("Walk returned errors: %v", errs)
+	}
+
+	var got []string
+	for _, fs := range stats {
+		rel, _ := filepath.Rel(tmpDir, fs.FilePath)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+
+	want := []string{"internal/core/core.go", "src/web/app.ts"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("counted files = %v, want %v", got, want)
+	}
+}
+
+func TestLanguageFilter(t *testing.T) {
+	filter, err := NewLanguageFilter([]string{"go", "TypeScript", "markdown"}, []string{"Markdown"})
+	if err != nil {
+		t.Fatalf("NewLanguageFilter error: %v", err)
+	}
+
+	tests := []struct {
+		lang string
+		want bool
+	}{
+		{"Go", true},
+		{"TypeScript", true},
+		{"Markdown", false},
+		{"Python", false},
+	}
+	for _, tt := range tests {
+		if got := filter.Match(tt.lang); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.lang, got, tt.want)
+		}
+	}
+
+	excludeOnly, err := NewLanguageFilter(nil, []string{"json"})
+	if err != nil {
+		t.Fatalf("NewLanguageFilter error: %v", err)
+	}
+	if excludeOnly.Match("JSON") || !excludeOnly.Match("Go") {
+		t.Error("expected only JSON to be excluded")
+	}
+
+	withOther, err := NewLanguageFilter([]string{"Go", "other"}, nil)
+	if err != nil {
+		t.Fatalf("NewLanguageFilter error: %v", err)
+	}
+	if !withOther.Match(otherLanguage.Name) || withOther.Match("Python") {
+		t.Error("expected Go and Other to be included")
+	}
+
+	if _, err := NewLanguageFilter([]string{"Klingon"}, nil); err == nil {
+		t.Error("expected an error for an unknown language")
+	}
+}
+
+func TestWalkerLanguageFilter(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range []string{"main.go", "app.ts", "README.md", "data.json", ".gitignore"} {
+		os.WriteFile(filepath.Join(tmpDir, file), []byte("x\n"), 0644)
+	}
+
+	filter, _ := NewLanguageFilter(nil, []string{"Markdown", "JSON", "Git Config"})
+	walker := NewWalker(tmpDir, 2)
+	walker.SetLanguageFilter(filter)
+	stats, _ := walker.Walk()
+
+	if len(stats) != 2 {
+		t.Errorf("expected 2 files, got %d", len(stats))
+	}
+	if walker.GetSkippedCount() != 3 {
+		t.Errorf("expected 3 skipped files, got %d", walker.GetSkippedCount())
+	}
+}
+
+func TestWalkerLanguageFilterOther(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, file := range []string{"main.go", "app.py", "notes.zzz"} {
+		os.WriteFile(filepath.Join(tmpDir, file), []byte("x\n"), 0644)
+	}
+
+	tests := []struct {
+		name      string
+		languages []string
+		exclude   []string
+		want      []string
+	}{
+		{"lang with Other", []string{"Go", "Other"}, nil, []string{"Go", "Other"}},
+		{"lang without Other", []string{"Go"}, nil, []string{"Go"}},
+		{"exclude Other", nil, []string{"other"}, []string{"Go", "Python"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			walker, err := newWalkerFromConfig(&Config{Path: tmpDir, Workers: 2, CountOther: true,
+				Languages: tt.languages, ExcludeLangs: tt.exclude})
+			if err != nil {
+				t.Fatalf("newWalkerFromConfig error: %v", err)
+			}
+			stats, _ := walker.Walk()
+
+			var got []string
+			for _, s := range stats {
+				got = append(got, s.Language)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("counted languages %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Files are read from disk when fsys is nil and from fsys otherwise. Paths use
+// the OS separator either way and are converted to slash separated paths,
+// relative to the root of fsys, when fsys is used.
+
+// fsPath converts a path to the form fs.FS expects
+func fsPath(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// statPath returns the file info of a path, following symlinks
+func statPath(fsys fs.FS, path string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(path)
+	}
+	return fs.Stat(fsys, fsPath(path))
+}
+
+// readDirPath returns the entries of a directory sorted by name
+func readDirPath(fsys fs.FS, path string) ([]fs.DirEntry, error) {
+	if fsys == nil {
+		return os.ReadDir(path)
+	}
+	return fs.ReadDir(fsys, fsPath(path))
+}
+
+// openPath opens a file for reading
+func openPath(fsys fs.FS, path string) (fs.File, error) {
+	if fsys == nil {
+		return os.Open(path)
+	}
+	return fsys.Open(fsPath(path))
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExitGateFailure is the exit code when a quality gate fails, distinct from
+// the exit code 1 of other errors
+const ExitGateFailure = 3
+
+// configGatesKey is the config file table holding per-path gates
+const configGatesKey = "gates"
+
+// Gate holds thresholds for the files matching a glob, or for every file when
+// Pattern is empty. Zero thresholds are disabled.
+type Gate struct {
+	Pattern         string
+	MaxFileLines    int
+	MaxTotalCode    int
+	MinCommentRatio float64
+	glob            *pathGlob
+}
+
+// Enabled reports whether the gate has any threshold
+func (g *Gate) Enabled() bool {
+	return g.MaxFileLines > 0 || g.MaxTotalCode > 0 || g.MinCommentRatio > 0
+}
+
+// GrowthLimit is the maximum growth of the total code lines against a
+// baseline, in lines or, if Percent is set, in percent of the baseline
+type GrowthLimit struct {
+	Value   float64
+	Percent bool
+}
+
+// ParseGrowthLimit parses a limit such as "500" or "5%"
+func ParseGrowthLimit(value string) (GrowthLimit, error) {
+	s := strings.TrimSpace(value)
+	percent := strings.HasSuffix(s, "%")
+	n, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || n < 0 {
+		return GrowthLimit{}, fmt.Errorf("invalid growth %q (expected lines or a percentage, e.g. 500 or 5%%)", value)
+	}
+	return GrowthLimit{Value: n, Percent: percent}, nil
+}
+
+func (g GrowthLimit) String() string {
+	if g.Percent {
+		return strconv.FormatFloat(g.Value, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(g.Value, 'f', -1, 64)
+}
+
+// GateViolation is a threshold exceeded by the scanned code
+type GateViolation struct {
+	Gate    string
+	Message string
+}
+
+func (v GateViolation) String() string {
+	if v.Gate == "" {
+		return v.Message
+	}
+	return v.Gate + ": " + v.Message
+}
+
+// GateError is returned when quality gates fail
+type GateError struct {
+	Violations []GateViolation
+}
+
+func (e *GateError) Error() string {
+	if len(e.Violations) == 1 {
+		return "1 quality gate violation"
+	}
+	return fmt.Sprintf("%d quality gate violations", len(e.Violations))
+}
+
+// GateChecker evaluates gates against counted files
+type GateChecker struct {
+	rootPath  string
+	gates     []*gateState
+	files     []GateViolation
+	codeLines int
+	baseline  int
+	maxGrowth *GrowthLimit
+
+	// incomplete is why the scan stopped before every file was observed
+	incomplete error
+}
+
+// gateState holds the statistics of the files matching a gate
+type gateState struct {
+	gate      *Gate
+	langStats map[string]*LanguageStats
+	codeLines int
+}
+
+// NewGateChecker creates a checker for the gates of files below rootPath
+func NewGateChecker(rootPath string, gates []*Gate) (*GateChecker, error) {
+	c := &GateChecker{rootPath: rootPath}
+	for _, gate := range gates {
+		if gate.Pattern != "" && gate.glob == nil {
+			glob, err := compilePathGlob(gate.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			gate.glob = glob
+		}
+		c.gates = append(c.gates, &gateState{gate: gate, langStats: make(map[string]*LanguageStats)})
+	}
+	return c, nil
+}
+
+// SetBaseline enables the growth check against the total code lines of a baseline
+func (c *GateChecker) SetBaseline(codeLines int, maxGrowth GrowthLimit) {
+	c.baseline = codeLines
+	c.maxGrowth = &maxGrowth
+}
+
+// SetIncomplete records that the scan stopped early, so the gates cannot pass
+// on the files observed so far
+func (c *GateChecker) SetIncomplete(reason error) {
+	c.incomplete = reason
+}
+
+// Observe adds a counted file to the gates matching it
+func (c *GateChecker) Observe(stats *FileStats) {
+	rel, err := filepath.Rel(c.rootPath, stats.FilePath)
+	if err != nil {
+		rel = stats.FilePath
+	}
+	rel = filepath.ToSlash(rel)
+	c.codeLines += stats.CodeLines
+
+	for _, state := range c.gates {
+		gate := state.gate
+		if gate.glob != nil && matchGlobs([]*pathGlob{gate.glob}, rel) == nil {
+			continue
+		}
+		if gate.MaxFileLines > 0 && stats.TotalLines > gate.MaxFileLines {
+			c.files = append(c.files, GateViolation{
+				Gate:    gate.Pattern,
+				Message: fmt.Sprintf("%s has %d lines, more than %d", rel, stats.TotalLines, gate.MaxFileLines),
+			})
+		}
+		addLanguageStats(state.langStats, stats)
+		state.codeLines += stats.CodeLines
+	}
+}
+
+// Violations returns the exceeded thresholds: an incomplete scan, oversized
+// files sorted by path, then total code and comment ratios per gate, then growth
+func (c *GateChecker) Violations() []GateViolation {
+	var violations []GateViolation
+	if c.incomplete != nil {
+		violations = append(violations, GateViolation{
+			Message: fmt.Sprintf("scan incomplete (%v), gates were not checked against every file", c.incomplete),
+		})
+	}
+
+	files := make([]GateViolation, len(c.files))
+	copy(files, c.files)
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Message != files[j].Message {
+			return files[i].Message < files[j].Message
+		}
+		return files[i].Gate < files[j].Gate
+	})
+	violations = append(violations, files...)
+
+	for _, state := range c.gates {
+		gate := state.gate
+		if gate.MaxTotalCode > 0 && state.codeLines > gate.MaxTotalCode {
+			violations = append(violations, GateViolation{
+				Gate:    gate.Pattern,
+				Message: fmt.Sprintf("total code is %d lines, more than %d", state.codeLines, gate.MaxTotalCode),
+			})
+		}
+		if gate.MinCommentRatio > 0 {
+			langs := make([]string, 0, len(state.langStats))
+			for lang := range state.langStats {
+				langs = append(langs, lang)
+			}
+			sort.Strings(langs)
+			for _, lang := range langs {
+				// Languages without comment syntax cannot meet a ratio
+				stats := state.langStats[lang]
+				if stats.CodeLines == 0 || !hasCommentSyntax(lang) {
+					continue
+				}
+				ratio := commentRatio(stats)
+				if ratio < gate.MinCommentRatio {
+					violations = append(violations, GateViolation{
+						Gate:    gate.Pattern,
+						Message: fmt.Sprintf("%s comment ratio is %.3f, less than %g", lang, ratio, gate.MinCommentRatio),
+					})
+				}
+			}
+		}
+	}
+
+	if c.maxGrowth != nil {
+		if v, ok := c.checkGrowth(); !ok {
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// checkGrowth compares the total code lines against the baseline
+func (c *GateChecker) checkGrowth() (GateViolation, bool) {
+	growth := c.codeLines - c.baseline
+	if c.maxGrowth.Percent {
+		percent := 0.0
+		if c.baseline > 0 {
+			percent = float64(growth) * 100 / float64(c.baseline)
+		} else if growth > 0 {
+			percent = 100
+		}
+		if percent > c.maxGrowth.Value {
+			return GateViolation{Message: fmt.Sprintf("total code grew from %d to %d lines (%+.1f%%), more than %s",
+				c.baseline, c.codeLines, percent, c.maxGrowth)}, false
+		}
+		return GateViolation{}, true
+	}
+	if float64(growth) > c.maxGrowth.Value {
+		return GateViolation{Message: fmt.Sprintf("total code grew from %d to %d lines (%+d), more than %s",
+			c.baseline, c.codeLines, growth, c.maxGrowth)}, false
+	}
+	return GateViolation{}, true
+}
+
+// Err returns a GateError listing the violations, or nil if all gates pass or
+// the checker is nil
+func (c *GateChecker) Err() error {
+	if c == nil {
+		return nil
+	}
+	if violations := c.Violations(); len(violations) > 0 {
+		return &GateError{Violations: violations}
+	}
+	return nil
+}
+
+// hasCommentSyntax reports whether the named language has comments
+func hasCommentSyntax(name string) bool {
+	lang := GetLanguageByName(name)
+	return lang != nil && (lang.SingleLineComment != "" || lang.MultiLineStart != "")
+}
+
+// commentRatio returns the share of comment lines among comment and code lines
+func commentRatio(stats *LanguageStats) float64 {
+	lines := stats.CommentLines + stats.CodeLines
+	if lines == 0 {
+		return 0
+	}
+	return float64(stats.CommentLines) / float64(lines)
+}
+
+// LoadBaseline reads the total code lines from a JSON report written by
+// "locc -f json"
+func LoadBaseline(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// The report may be followed by the timing line of a run without -q
+	var report struct {
+		Total *struct {
+			Code *int `json:"code"`
+		} `json:"total"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&report); err != nil {
+		return 0, fmt.Errorf("invalid baseline %s: %v", path, err)
+	}
+	if report.Total == nil || report.Total.Code == nil {
+		return 0, fmt.Errorf("invalid baseline %s: no total code lines (expected a report from -f json)", path)
+	}
+	return *report.Total.Code, nil
+}
+
+// parseConfigGates reads the gates table of a config file, keyed by glob
+func parseConfigGates(value interface{}) ([]*Gate, error) {
+	table, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a table of globs", configGatesKey)
+	}
+
+	var gates []*Gate
+	for _, pattern := range sortedKeys(table) {
+		thresholds, ok := table[pattern].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s.%q must be a table of thresholds", configGatesKey, pattern)
+		}
+		glob, err := compilePathGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		gate := &Gate{Pattern: pattern, glob: glob}
+		for _, key := range sortedKeys(thresholds) {
+			s, err := configString(thresholds[key])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s.%q.%s: %v", configGatesKey, pattern, key, err)
+			}
+			switch key {
+			case "max-file-lines":
+				gate.MaxFileLines, err = strconv.Atoi(s)
+			case "max-total-code":
+				gate.MaxTotalCode, err = strconv.Atoi(s)
+			case "min-comment-ratio":
+				gate.MinCommentRatio, err = strconv.ParseFloat(s, 64)
+			default:
+				return nil, fmt.Errorf("unknown key %q in %s.%q", key, configGatesKey, pattern)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q for %s.%q.%s", s, configGatesKey, pattern, key)
+			}
+		}
+		gates = append(gates, gate)
+	}
+	return gates, nil
+}
+
+// PrintGateViolations prints the failed gates to stderr
+func PrintGateViolations(violations []GateViolation) {
+	fmt.Fprintf(os.Stderr, "\nQuality gates failed:\n")
+	for _, v := range violations {
+		fmt.Fprintf(os.Stderr, "  - %s\n", v)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gateFiles(root string) []*FileStats {
+	return []*FileStats{
+		{FilePath: filepath.Join(root, "src", "big.go"), Language: "Go", CommentLines: 10, CodeLines: 1200, TotalLines: 1250},
+		{FilePath: filepath.Join(root, "src", "small.go"), Language: "Go", CommentLines: 20, CodeLines: 80, TotalLines: 100},
+		{FilePath: filepath.Join(root, "scripts", "run.py"), Language: "Python", CommentLines: 0, CodeLines: 50, TotalLines: 50},
+		{FilePath: filepath.Join(root, "README.md"), Language: "Markdown", CodeLines: 30, TotalLines: 40},
+	}
+}
+
+func TestGateChecker(t *testing.T) {
+	root := filepath.Join("repo")
+	checker, err := NewGateChecker(root, []*Gate{
+		{MaxFileLines: 1000, MaxTotalCode: 1000, MinCommentRatio: 0.05},
+		{Pattern: "src/", MaxFileLines: 90, MinCommentRatio: 0.2},
+		{Pattern: "scripts/**", MaxTotalCode: 100},
+	})
+	if err != nil {
+		t.Fatalf("NewGateChecker error: %v", err)
+	}
+	for _, fs := range gateFiles(root) {
+		checker.Observe(fs)
+	}
+
+	var got []string
+	for _, v := range checker.Violations() {
+		got = append(got, v.String())
+	}
+	want := []string{
+		"src/big.go has 1250 lines, more than 1000",
+		"src/: src/big.go has 1250 lines, more than 90",
+		"src/: src/small.go has 100 lines, more than 90",
+		"total code is 1360 lines, more than 1000",
+		"Go comment ratio is 0.023, less than 0.05",
+		"Python comment ratio is 0.000, less than 0.05",
+		"src/: Go comment ratio is 0.023, less than 0.2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var gateErr *GateError
+	if err := checker.Err(); !errors.As(err, &gateErr) || len(gateErr.Violations) != len(want) {
+		t.Errorf("Err() = %v, want a GateError with %d violations", err, len(want))
+	}
+
+	var nilChecker *GateChecker
+	if err := nilChecker.Err(); err != nil {
+		t.Errorf("nil checker Err() = %v, want nil", err)
+	}
+}
+
+func TestGateCheckerGrowth(t *testing.T) {
+	tests := []struct {
+		limit    string
+		baseline int
+		want     string
+	}{
+		{"100", 1300, ""},
+		{"50", 1300, "total code grew from 1300 to 1360 lines (+60), more than 50"},
+		{"5%", 1300, ""},
+		{"4%", 1300, "total code grew from 1300 to 1360 lines (+4.6%), more than 4%"},
+		{"0", 2000, ""},
+		{"10%", 0, "total code grew from 0 to 1360 lines (+100.0%), more than 10%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			limit, err := ParseGrowthLimit(tt.limit)
+			if err != nil {
+				t.Fatalf("ParseGrowthLimit(%q) error: %v", tt.limit, err)
+			}
+			checker, _ := NewGateChecker("repo", nil)
+			checker.SetBaseline(tt.baseline, limit)
+			for _, fs := range gateFiles("repo") {
+				checker.Observe(fs)
+			}
+
+			violations := checker.Violations()
+			if tt.want == "" {
+				if len(violations) != 0 {
+					t.Errorf("unexpected violations: %v", violations)
+				}
+				return
+			}
+			if len(violations) != 1 || violations[0].String() != tt.want {
+				t.Errorf("Violations = %v, want %q", violations, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "abc", "-5", "5%%"} {
+		if _, err := ParseGrowthLimit(invalid); err == nil {
+			t.Errorf("ParseGrowthLimit(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestLoadBaseline(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Reports written without -q end with the timing line
+	report := filepath.Join(tmpDir, "report.json")
+	os.WriteFile(report, []byte(`{
+  "languages": {},
+  "total": {"files": 3, "blank": 1, "comment": 2, "code": 42, "total": 45}
+}
+Time elapsed: 3ms
+`), 0644)
+	code, err := LoadBaseline(report)
+	if err != nil || code != 42 {
+		t.Errorf("LoadBaseline = %d, %v, want 42", code, err)
+	}
+
+	tree := filepath.Join(tmpDir, "tree.json")
+	os.WriteFile(tree, []byte(`{"path": ".", "children": []}`), 0644)
+	if _, err := LoadBaseline(tree); err == nil || !strings.Contains(err.Error(), "no total code lines") {
+		t.Errorf("LoadBaseline(tree) error = %v, want a missing total error", err)
+	}
+}
+
+func TestParseConfigGates(t *testing.T) {
+	values, err := ParseTOML(`
+[gates."src/**"]
+max-file-lines = 500
+min-comment-ratio = 0.1
+
+[gates.legacy]
+max-total-code = 20000
+`)
+	if err != nil {
+		t.Fatalf("ParseTOML error: %v", err)
+	}
+	gates, err := parseConfigGates(values[configGatesKey])
+	if err != nil {
+		t.Fatalf("parseConfigGates error: %v", err)
+	}
+	if len(gates) != 2 {
+		t.Fatalf("expected 2 gates, got %d", len(gates))
+	}
+	if g := gates[0]; g.Pattern != "legacy" || g.MaxTotalCode != 20000 {
+		t.Errorf("unexpected gate: %+v", g)
+	}
+	if g := gates[1]; g.Pattern != "src/**" || g.MaxFileLines != 500 || g.MinCommentRatio != 0.1 {
+		t.Errorf("unexpected gate: %+v", g)
+	}
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`gates = 5`, "gates must be a table of globs"},
+		{"[gates]\nsrc = 5", `gates."src" must be a table of thresholds`},
+		{"[gates.src]\nmax-lines = 5", `unknown key "max-lines" in gates."src"`},
+		{"[gates.src]\nmax-file-lines = \"many\"", `invalid value "many" for gates."src".max-file-lines`},
+	}
+	for _, tt := range tests {
+		values, err := ParseTOML(tt.src)
+		if err != nil {
+			t.Fatalf("ParseTOML(%q) error: %v", tt.src, err)
+		}
+		if _, err := parseConfigGates(values[configGatesKey]); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseConfigGates(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestRunGates(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+
+	config := &Config{Path: tmpDir, Workers: 2, OutputFormat: "compact", Quiet: true, MaxFileLines: 2}
+	var err error
+	captureStdout(func() {
+		err = Run(config)
+	})
+	var gateErr *GateError
+	if !errors.As(err, &gateErr) || len(gateErr.Violations) != 1 {
+		t.Fatalf("Run error = %v, want one gate violation", err)
+	}
+
+	config.MaxFileLines = 3
+	captureStdout(func() {
+		err = Run(config)
+	})
+	if err != nil {
+		t.Errorf("Run error = %v, want nil", err)
+	}
+
+	// A scan stopped by the timeout fails the gates even if the counted files pass
+	config.Timeout = time.Nanosecond
+	captureStdout(func() {
+		err = Run(config)
+	})
+	if !errors.As(err, &gateErr) || len(gateErr.Violations) != 1 || !strings.Contains(gateErr.Violations[0].Message, "scan incomplete") {
+		t.Fatalf("Run error = %v, want a scan incomplete violation", err)
+	}
+	config.Timeout = 0
+
+	config.MaxGrowth = "5%"
+	if err := Run(config); err == nil || !strings.Contains(err.Error(), "--baseline") {
+		t.Errorf("Run error = %v, want a missing baseline error", err)
+	}
+}
@@ -1,5 +1,11 @@
 package main
 
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
 // Language represents a programming language with its comment patterns
 type Language struct {
 	Name              string
@@ -883,7 +889,87 @@ func GetLanguageByFilename(filename string) *Language {
 	return nil
 }
 
+var (
+	languageNamesOnce sync.Once
+	// languageNames maps lower-case language names to their definitions
+	languageNames map[string]*Language
+)
+
+// GetLanguageByName returns the language definition for a language name,
+// matched case-insensitively, or nil if there is none. Extensions are indexed
+// before filenames, each in sorted order, so the result is the same on every call.
+func GetLanguageByName(name string) *Language {
+	languageNamesOnce.Do(func() {
+		languageNames = make(map[string]*Language)
+		for _, langs := range []map[string]*Language{Languages, FilenameLanguages, HiddenFileLanguages} {
+			keys := make([]string, 0, len(langs))
+			for key := range langs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				lower := strings.ToLower(langs[key].Name)
+				if languageNames[lower] == nil {
+					languageNames[lower] = langs[key]
+				}
+			}
+		}
+	})
+	return languageNames[strings.ToLower(name)]
+}
+
 // IsBinaryExtension checks if the file extension is a binary file
 func IsBinaryExtension(ext string) bool {
 	return BinaryExtensions[ext]
 }
+
+// LanguageInfo describes a language and every way the registry detects it
+type LanguageInfo struct {
+	Name              string
+	Extensions        []string
+	Filenames         []string
+	SingleLineComment string
+	MultiLineStart    string
+	MultiLineEnd      string
+}
+
+// ListLanguages returns every registered language sorted by name, with the
+// extensions and filenames mapped to it
+func ListLanguages() []*LanguageInfo {
+	byName := make(map[string]*LanguageInfo)
+	info := func(lang *Language) *LanguageInfo {
+		li, exists := byName[lang.Name]
+		if !exists {
+			li = &LanguageInfo{
+				Name:              lang.Name,
+				SingleLineComment: lang.SingleLineComment,
+				MultiLineStart:    lang.MultiLineStart,
+				MultiLineEnd:      lang.MultiLineEnd,
+			}
+			byName[lang.Name] = li
+		}
+		return li
+	}
+
+	for ext, lang := range Languages {
+		li := info(lang)
+		li.Extensions = append(li.Extensions, ext)
+	}
+	for _, names := range []map[string]*Language{FilenameLanguages, HiddenFileLanguages} {
+		for name, lang := range names {
+			li := info(lang)
+			li.Filenames = append(li.Filenames, name)
+		}
+	}
+
+	langs := make([]*LanguageInfo, 0, len(byName))
+	for _, li := range byName {
+		sort.Strings(li.Extensions)
+		sort.Strings(li.Filenames)
+		langs = append(langs, li)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		return langs[i].Name < langs[j].Name
+	})
+	return langs
+}
@@ -168,3 +168,66 @@ func TestGetLanguageByFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestListLanguages(t *testing.T) {
+	langs := ListLanguages()
+
+	byName := make(map[string]*LanguageInfo)
+	for i, li := range langs {
+		if i > 0 && langs[i-1].Name >= li.Name {
+			t.Errorf("languages not sorted or not unique: %q before %q", langs[i-1].Name, li.Name)
+		}
+		byName[li.Name] = li
+	}
+
+	// Every registry entry must be listed under its language
+	for ext, lang := range Languages {
+		if !containsString(byName[lang.Name].Extensions, ext) {
+			t.Errorf("extension %q missing from %q", ext, lang.Name)
+		}
+	}
+	for name, lang := range FilenameLanguages {
+		if !containsString(byName[lang.Name].Filenames, name) {
+			t.Errorf("filename %q missing from %q", name, lang.Name)
+		}
+	}
+	for name, lang := range HiddenFileLanguages {
+		if !containsString(byName[lang.Name].Filenames, name) {
+			t.Errorf("filename %q missing from %q", name, lang.Name)
+		}
+	}
+
+	if goLang := byName["Go"]; goLang == nil || goLang.SingleLineComment != "//" || goLang.MultiLineStart != "/*" {
+		t.Errorf("unexpected Go entry: %+v", goLang)
+	}
+}
+
+func TestGetLanguageByName(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{"Go", "Go", true},
+		{"typescript", "TypeScript", true},
+		{"C++", "C++", true},
+		{"MARKDOWN", "Markdown", true},
+		{"Klingon", "", false},
+	}
+
+	for _, tt := range tests {
+		lang := GetLanguageByName(tt.name)
+		if (lang != nil) != tt.wantOk || lang != nil && lang.Name != tt.want {
+			t.Errorf("GetLanguageByName(%q) = %+v; want %q, %v", tt.name, lang, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
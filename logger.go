@@ -165,6 +165,10 @@ func (e *FileError) Error() string {
 	return fmt.Sprintf("error processing file %s: %v", e.FilePath, e.Err)
 }
 
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
 // NewFileError creates a new FileError
 func NewFileError(filePath string, err error) *FileError {
 	return &FileError{
@@ -209,6 +213,24 @@ func NewPermissionError(path string, err error) *PermissionError {
 	}
 }
 
+// SymlinkLoopError represents a symlinked directory that points back to one of its ancestors
+type SymlinkLoopError struct {
+	Path   string
+	Target string
+}
+
+func (e *SymlinkLoopError) Error() string {
+	return fmt.Sprintf("symlink loop: %s -> %s", e.Path, e.Target)
+}
+
+// NewSymlinkLoopError creates a new SymlinkLoopError
+func NewSymlinkLoopError(path, target string) *SymlinkLoopError {
+	return &SymlinkLoopError{
+		Path:   path,
+		Target: target,
+	}
+}
+
 // IsPermissionError checks if an error is a permission error
 func IsPermissionError(err error) bool {
 	return os.IsPermission(err)
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,24 +23,121 @@ const (
 // Config holds the application configuration
 type Config struct {
 	Path            string
+	ConfigFile      string
 	Workers         int
+	DirWorkers      int
 	IncludeHidden   bool
-	ExcludeDirs     []string
+	FollowSymlinks  bool
+	OneFileSystem   bool
+	Includes        []string
+	Excludes        []string
+	IncludeRegex    []string
+	ExcludeRegex    []string
 	ExcludePatterns []string
+	Languages       []string
+	ExcludeLangs    []string
+	ListLanguages   bool
+	MaxFileSize     int64
+	MinFileSize     int64
+	MaxDepth        int
+	FilesFrom       string
+	ChangedSince    string
+	Stdin           bool
+	StdinFilename   string
+	Explain         string
+	CountOther      bool
+	Archives        bool
 	OutputFormat    string
+	TestSplit       bool
+	TestPatterns    []string
+	ByDir           bool
+	DirDepth        int
+	ByProject       bool
+	ByOwner         bool
+	Timeout         time.Duration
+	FileTimeout     time.Duration
+	MaxFileLines    int
+	MaxTotalCode    int
+	MinCommentRatio float64
+	MaxGrowth       string
+	Baseline        string
+	Gates           []*Gate
+	LangOverrides   map[string]*Language
 	ShowErrors      bool
 	Verbose         bool
 	Quiet           bool
 }
 
 func main() {
-	config := parseFlags()
+	// Dispatch subcommands
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				if err == flag.ErrHelp {
+					os.Exit(0)
+				}
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	config, err := parseFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	if err := Run(config); err != nil {
+		var gateErr *GateError
+		if errors.As(err, &gateErr) {
+			PrintGateViolations(gateErr.Violations)
+			os.Exit(ExitGateFailure)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// commands maps subcommand names to their entry points
+var commands = map[string]func(args []string) error{
+	"authors": func(args []string) error {
+		config, err := parseAuthorsFlags(args)
+		if err != nil {
+			return err
+		}
+		return RunAuthors(config)
+	},
+	"snapshot": func(args []string) error {
+		config, err := parseSnapshotFlags(args)
+		if err != nil {
+			return err
+		}
+		return RunSnapshot(config)
+	},
+	"compare": func(args []string) error {
+		config, err := parseCompareFlags(args)
+		if err != nil {
+			return err
+		}
+		return RunCompare(config)
+	},
+	"compare-dirs": func(args []string) error {
+		config, err := parseCompareDirsFlags(args)
+		if err != nil {
+			return err
+		}
+		return RunCompareDirs(config)
+	},
+	"serve": func(args []string) error {
+		config, err := parseServeFlags(args)
+		if err != nil {
+			return err
+		}
+		return RunServe(config)
+	},
+}
+
 // Run executes the application logic with the given configuration
 func Run(config *Config) error {
 	if config.Verbose {
@@ -45,12 +146,97 @@ func Run(config *Config) error {
 		SetLogLevel(LogLevelSilent)
 	}
 
+	if config.ListLanguages {
+		if config.OutputFormat == "json" {
+			PrintLanguageListJSON(ListLanguages())
+		} else {
+			PrintLanguageList(ListLanguages())
+		}
+		return nil
+	}
+
 	// Validate path
 	if config.Path == "" {
 		config.Path = "."
 	}
 
-	info, err := os.Stat(config.Path)
+	stdin := config.Stdin || config.StdinFilename != ""
+	if stdin && (config.FilesFrom != "" || config.ChangedSince != "" || config.Explain != "") {
+		return fmt.Errorf("--stdin cannot be used with --files-from, --changed-since or --explain")
+	}
+
+	// Standard input is reported as a file in the current directory
+	var info os.FileInfo
+	var err error
+	rootPath := "."
+	if !stdin {
+		info, err = os.Stat(config.Path)
+		if err != nil {
+			return err
+		}
+
+		// Breakdowns are reported relative to the scanned directory
+		rootPath = config.Path
+		if !info.IsDir() {
+			rootPath = filepath.Dir(config.Path)
+		}
+	}
+
+	if (config.FilesFrom != "" || config.ChangedSince != "") && !info.IsDir() {
+		return fmt.Errorf("--files-from and --changed-since need a directory to scan, got %s", config.Path)
+	}
+
+	if config.Explain != "" {
+		if !info.IsDir() {
+			return fmt.Errorf("--explain needs a directory to scan, got %s", config.Path)
+		}
+		walker, err := newWalkerFromConfig(config)
+		if err != nil {
+			return err
+		}
+		// Relative paths are relative to the scanned directory, like the files
+		// the report lists
+		explainPath := config.Explain
+		if !filepath.IsAbs(explainPath) {
+			explainPath = filepath.Join(config.Path, explainPath)
+		}
+		explanation, err := walker.Explain(explainPath)
+		if err != nil {
+			return err
+		}
+		PrintExplanation(explanation)
+		return nil
+	}
+
+	// JSON output is a single document, so only one breakdown can be rendered
+	if config.OutputFormat == "json" && countTrue(config.ByDir, config.ByProject, config.ByOwner, config.TestSplit) > 1 {
+		return fmt.Errorf("only one of --by-dir, --by-project, --by-owner and --tests can be used with JSON output")
+	}
+
+	// NDJSON output streams files without keeping them, so breakdowns are unavailable
+	if config.OutputFormat == "ndjson" && countTrue(config.ByDir, config.ByProject, config.ByOwner, config.TestSplit) > 0 {
+		return fmt.Errorf("--by-dir, --by-project, --by-owner and --tests cannot be used with NDJSON output")
+	}
+
+	// Prometheus output only exposes the per-language totals and run metadata
+	if config.OutputFormat == "prometheus" && countTrue(config.ByDir, config.ByProject, config.ByOwner, config.TestSplit) > 0 {
+		return fmt.Errorf("--by-dir, --by-project, --by-owner and --tests cannot be used with Prometheus output")
+	}
+
+	// Load code owners up front so a missing file is reported before scanning
+	var codeOwners *CodeOwners
+	if config.ByOwner {
+		codeOwners, err = LoadCodeOwners(rootPath)
+		if err != nil {
+			return err
+		}
+		if codeOwners == nil {
+			return fmt.Errorf("no CODEOWNERS file found in %s", rootPath)
+		}
+	}
+
+	// Set up quality gates up front so a bad baseline is reported before scanning
+	gates, err := newGateCheckerFromConfig(config, rootPath)
 	if err != nil {
 		return err
 	}
@@ -59,43 +245,84 @@ func Run(config *Config) error {
 	startTime := time.Now()
 
 	var fileStats []*FileStats
+	var langStats map[string]*LanguageStats
 	var errors []error
+	var projects []Project
+	var partialReason error
+	skips := make(SkipStats)
+	var unrecognized []*UnrecognizedStats
 	processedFiles := 0
 	skippedFiles := 0
 
-	if !info.IsDir() {
+	if stdin {
+		// Standard input mode
+		lang, name, err := stdinLanguage(config)
+		if err != nil {
+			return err
+		}
+		langFilter, err := NewLanguageFilter(config.Languages, config.ExcludeLangs)
+		if err != nil {
+			return err
+		}
+
+		if !langFilter.Match(lang.Name) {
+			skippedFiles = 1
+			skips.add(SkipLanguage, name)
+		} else {
+			stats, err := countContent(os.Stdin, name, lang, false)
+			if err != nil {
+				return fmt.Errorf("reading standard input: %v", err)
+			}
+			stats.Extension = strings.ToLower(filepath.Ext(name))
+			stats.IsTest = IsTestFile(name, lang.Name, config.TestPatterns)
+			fileStats = append(fileStats, stats)
+			processedFiles = 1
+			if config.OutputFormat == "ndjson" {
+				PrintFileNDJSON(stats, rootPath)
+			}
+		}
+	} else if !info.IsDir() && !(config.Archives && archiveFormat(config.Path) != "") {
 		// Single file mode
 		ext := strings.ToLower(filepath.Ext(config.Path))
-		lang := GetLanguage(ext)
+		lang, _ := lookupLanguage(config.LangOverrides, config.Path, filepath.Base(config.Path))
+		langFilter, err := NewLanguageFilter(config.Languages, config.ExcludeLangs)
+		if err != nil {
+			return err
+		}
+
 		if lang == nil {
-			lang = GetLanguageByFilename(filepath.Base(config.Path))
+			unrecognized = []*UnrecognizedStats{{Name: unrecognizedName(config.Path), Files: 1, Bytes: info.Size()}}
+			if config.CountOther {
+				lang = otherLanguage
+			}
 		}
 
 		if lang == nil {
 			skippedFiles = 1
+			skips.add(SkipUnsupported, config.Path)
+		} else if !langFilter.Match(lang.Name) {
+			skippedFiles = 1
+			skips.add(SkipLanguage, config.Path)
 		} else {
-			stats, err := CountLines(config.Path, lang)
+			stats, err := countJob(nil, FileJob{Path: config.Path, Language: lang})
 			if err != nil {
 				errors = append(errors, err)
 			} else {
 				stats.Extension = ext
+				// Classify by the path relative to rootPath, as the walker does
+				stats.IsTest = IsTestFile(filepath.Base(config.Path), lang.Name, config.TestPatterns)
 				fileStats = append(fileStats, stats)
 				processedFiles = 1
+				if config.OutputFormat == "ndjson" {
+					PrintFileNDJSON(stats, rootPath)
+				}
 			}
 		}
 	} else {
-		// Directory mode
-		walker := NewWalker(config.Path, config.Workers)
-		walker.SetIncludeHidden(config.IncludeHidden)
-
-		// Add any additional exclude directories
-		for _, dir := range config.ExcludeDirs {
-			walker.AddExcludeDir(dir)
-		}
-
-		// Add exclude patterns
-		for _, pattern := range config.ExcludePatterns {
-			walker.AddExcludePattern(pattern)
+		// Directory mode, also used to read a single archive
+		walker, err := newWalkerFromConfig(config)
+		if err != nil {
+			return err
 		}
 
 		if config.Verbose {
@@ -103,24 +330,93 @@ func Run(config *Config) error {
 			LogDebug("Using %d workers", config.Workers)
 		}
 
-		// Walk and count
-		fileStats, errors = walker.Walk()
+		// Stop on Ctrl-C or when the timeout expires, keeping what was counted so far
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+			defer cancel()
+		}
+
+		// Walk and count; NDJSON prints each file as soon as it is counted
+		if config.OutputFormat == "ndjson" {
+			langStats = make(map[string]*LanguageStats)
+			errors = walker.WalkFunc(ctx, func(stats *FileStats) {
+				PrintFileNDJSON(stats, rootPath)
+				addLanguageStats(langStats, stats)
+				if gates != nil {
+					gates.Observe(stats)
+				}
+			})
+		} else {
+			fileStats, errors = walker.WalkContext(ctx)
+		}
+		if walker.IsPartial() {
+			partialReason = ctx.Err()
+		}
 		processedFiles = walker.GetProcessedCount()
 		skippedFiles = walker.GetSkippedCount()
+		skips = walker.GetSkipStats()
+		unrecognized = walker.GetUnrecognized()
+		projects = walker.GetProjects()
 	}
 
 	// Calculate elapsed time
 	elapsed := time.Since(startTime)
 
+	if gates != nil {
+		for _, stats := range fileStats {
+			gates.Observe(stats)
+		}
+		// A scan cut short must not pass the gates on the files it reached
+		if partialReason != nil {
+			gates.SetIncomplete(partialReason)
+		}
+	}
+
 	// Aggregate statistics
-	langStats := AggregateStats(fileStats)
+	if langStats == nil {
+		langStats = AggregateStats(fileStats)
+	}
 	total := TotalStats(langStats)
 	errorCount := len(errors)
 
+	summary := &RunSummary{
+		ProcessedFiles: processedFiles,
+		SkippedFiles:   skippedFiles,
+		ErrorCount:     errorCount,
+		Partial:        partialReason != nil,
+		Skipped:        skips,
+		Unrecognized:   unrecognized,
+	}
+
+	report := &reportData{
+		rootPath:   rootPath,
+		fileStats:  fileStats,
+		projects:   projects,
+		codeOwners: codeOwners,
+		langStats:  langStats,
+		total:      total,
+		summary:    summary,
+	}
+
 	// Output results based on format
 	switch config.OutputFormat {
 	case "json":
-		PrintJSON(langStats, total)
+		printJSONReport(config, report)
+	case "ndjson":
+		if config.ShowErrors {
+			for _, err := range errors {
+				PrintErrorNDJSON(err)
+			}
+		}
+		PrintSummaryNDJSON(langStats, total, summary)
+		return gates.Err()
+	case "prometheus":
+		// Nothing else may be printed for node_exporter to accept the file
+		PrintPrometheus(config.Path, langStats, summary, elapsed)
+		return gates.Err()
 	case "compact":
 		PrintCompact(total)
 	case "formatted":
@@ -129,6 +425,22 @@ func Run(config *Config) error {
 		PrintResults(langStats, total, processedFiles, skippedFiles, errorCount)
 	}
 
+	// Explain what was skipped
+	if !config.Quiet && (config.OutputFormat == "default" || config.OutputFormat == "formatted") {
+		PrintSkipBreakdown(skips)
+		PrintUnrecognized(unrecognized, config.OutputFormat == "formatted")
+	}
+
+	// Show additional breakdowns if requested
+	if config.OutputFormat != "json" {
+		printBreakdowns(config, report)
+	}
+
+	// Flag results of an interrupted scan
+	if partialReason != nil && config.OutputFormat != "json" {
+		PrintPartialNotice(partialReason)
+	}
+
 	// Show errors if requested
 	if config.ShowErrors && len(errors) > 0 {
 		PrintErrors(errors)
@@ -139,43 +451,144 @@ func Run(config *Config) error {
 		fmt.Printf("Time elapsed: %v\n", elapsed.Round(time.Millisecond))
 	}
 
-	return nil
+	return gates.Err()
 }
 
-func parseFlags() *Config {
-	config := &Config{}
+// reportData holds the collected results used to render breakdowns
+type reportData struct {
+	rootPath   string
+	fileStats  []*FileStats
+	projects   []Project
+	codeOwners *CodeOwners
+	langStats  map[string]*LanguageStats
+	total      *LanguageStats
+	summary    *RunSummary
+}
 
-	// Define flags
-	flag.StringVar(&config.Path, "path", ".", "Path to the directory to analyze")
-	flag.StringVar(&config.Path, "p", ".", "Path to the directory to analyze (shorthand)")
+// printJSONReport prints the JSON document for the requested breakdown
+func printJSONReport(config *Config, report *reportData) {
+	switch {
+	case config.ByDir:
+		PrintDirTreeJSON(AggregateDirStats(report.fileStats, report.rootPath, config.DirDepth))
+	case config.ByProject:
+		PrintProjectsJSON(AggregateProjectStats(report.fileStats, report.rootPath, report.projects), report.total)
+	case config.ByOwner:
+		PrintOwnersJSON(AggregateOwnerStats(report.fileStats, report.rootPath, report.codeOwners), report.total)
+	case config.TestSplit:
+		splitStats := AggregateTestStats(report.fileStats)
+		PrintTestSplitJSON(splitStats, TotalTestStats(splitStats))
+	default:
+		PrintJSONSummary(report.langStats, report.total, report.summary)
+	}
+}
 
-	flag.IntVar(&config.Workers, "workers", runtime.NumCPU(), "Number of worker goroutines")
-	flag.IntVar(&config.Workers, "w", runtime.NumCPU(), "Number of worker goroutines (shorthand)")
+// printBreakdowns prints the requested breakdown tables after the language table
+func printBreakdowns(config *Config, report *reportData) {
+	formatted := config.OutputFormat == "formatted"
+	if config.ByDir {
+		PrintDirTree(AggregateDirStats(report.fileStats, report.rootPath, config.DirDepth), formatted)
+	}
+	if config.ByProject {
+		PrintProjects(AggregateProjectStats(report.fileStats, report.rootPath, report.projects), formatted)
+	}
+	if config.ByOwner {
+		PrintOwners(AggregateOwnerStats(report.fileStats, report.rootPath, report.codeOwners), formatted)
+	}
+	if config.TestSplit {
+		splitStats := AggregateTestStats(report.fileStats)
+		PrintTestSplit(splitStats, TotalTestStats(splitStats))
+	}
+}
 
-	flag.BoolVar(&config.IncludeHidden, "hidden", false, "Include hidden files and directories")
-	flag.BoolVar(&config.IncludeHidden, "H", false, "Include hidden files and directories (shorthand)")
+// newGateCheckerFromConfig creates a GateChecker from the threshold options and
+// the gates of the config file, or returns nil if no gate is set
+func newGateCheckerFromConfig(config *Config, rootPath string) (*GateChecker, error) {
+	var gates []*Gate
+	global := &Gate{
+		MaxFileLines:    config.MaxFileLines,
+		MaxTotalCode:    config.MaxTotalCode,
+		MinCommentRatio: config.MinCommentRatio,
+	}
+	if global.Enabled() {
+		gates = append(gates, global)
+	}
+	gates = append(gates, config.Gates...)
 
-	flag.StringVar(&config.OutputFormat, "format", "default", "Output format: default, json, compact, formatted")
-	flag.StringVar(&config.OutputFormat, "f", "default", "Output format (shorthand)")
+	if config.MaxGrowth == "" && config.Baseline != "" {
+		return nil, fmt.Errorf("--baseline needs --max-growth")
+	}
+	if config.MaxGrowth == "" && len(gates) == 0 {
+		return nil, nil
+	}
 
-	flag.BoolVar(&config.ShowErrors, "errors", false, "Show detailed error messages")
-	flag.BoolVar(&config.ShowErrors, "e", false, "Show detailed error messages (shorthand)")
+	checker, err := NewGateChecker(rootPath, gates)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxGrowth != "" {
+		limit, err := ParseGrowthLimit(config.MaxGrowth)
+		if err != nil {
+			return nil, err
+		}
+		if config.Baseline == "" {
+			return nil, fmt.Errorf("--max-growth needs a --baseline report to compare against")
+		}
+		baseline, err := LoadBaseline(config.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		checker.SetBaseline(baseline, limit)
+	}
+	return checker, nil
+}
 
-	flag.BoolVar(&config.Verbose, "verbose", false, "Enable verbose output")
-	flag.BoolVar(&config.Verbose, "v", false, "Enable verbose output (shorthand)")
+// stdinName is the path standard input is reported under without --stdin-filename
+const stdinName = "(stdin)"
+
+// stdinLanguage returns the language and reported path of standard input. The
+// language is detected from --stdin-filename, or else taken from a --lang
+// naming a single language, or else "Other" with --other.
+func stdinLanguage(config *Config) (*Language, string, error) {
+	name := stdinName
+	if config.StdinFilename != "" {
+		name = config.StdinFilename
+		if lang, _ := lookupLanguage(config.LangOverrides, name, filepath.Base(name)); lang != nil {
+			return lang, name, nil
+		}
+	}
+	if len(config.Languages) == 1 {
+		lang := resolveLanguageName(config.Languages[0])
+		if lang == nil {
+			return nil, "", fmt.Errorf("unknown language %q (see --list-languages)", config.Languages[0])
+		}
+		return lang, name, nil
+	}
+	if config.CountOther {
+		return otherLanguage, name, nil
+	}
+	if config.StdinFilename != "" {
+		return nil, "", fmt.Errorf("no language is registered for %s; name one with --lang", config.StdinFilename)
+	}
+	return nil, "", fmt.Errorf("--stdin needs --lang with a single language or --stdin-filename to detect it")
+}
 
-	flag.BoolVar(&config.Quiet, "quiet", false, "Suppress non-essential output")
-	flag.BoolVar(&config.Quiet, "q", false, "Suppress non-essential output (shorthand)")
+// countTrue returns the number of true values
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
 
-	// Custom exclude directories
-	var excludeDirs string
-	flag.StringVar(&excludeDirs, "exclude", "", "Comma-separated list of directories to exclude")
-	flag.StringVar(&excludeDirs, "x", "", "Comma-separated list of directories to exclude (shorthand)")
+func parseFlags() (*Config, error) {
+	config := &Config{}
 
-	// Custom exclude patterns
-	var excludePatterns string
-	flag.StringVar(&excludePatterns, "ignore", "", "Comma-separated list of patterns to exclude files (e.g., \"*_test.go,*.log\")")
-	flag.StringVar(&excludePatterns, "i", "", "Comma-separated list of patterns to exclude files (shorthand)")
+	// Define flags
+	registerScanFlags(flag.CommandLine, config)
+	registerMainFlags(flag.CommandLine, config)
 
 	// Version flag
 	version := flag.Bool("version", false, "Print version information")
@@ -204,23 +617,272 @@ func parseFlags() *Config {
 		os.Exit(0)
 	}
 
-	// Parse exclude directories
-	if excludeDirs != "" {
-		config.ExcludeDirs = splitAndTrim(excludeDirs, ",")
-	}
-
-	// Parse exclude patterns
-	if excludePatterns != "" {
-		config.ExcludePatterns = splitAndTrim(excludePatterns, ",")
-	}
-
 	// Handle positional argument (path)
 	args := flag.Args()
 	if len(args) > 0 {
 		config.Path = args[0]
 	}
 
-	return config
+	// Fill in options from environment variables and config files
+	if err := applyConfig(flag.CommandLine, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// registerMainFlags defines the flags of the main command that its
+// subcommands do not share
+func registerMainFlags(fs *flag.FlagSet, config *Config) {
+	fs.DurationVar(&config.Timeout, "timeout", 0, "Stop the scan after this duration and report partial results (e.g., 30s, 5m)")
+	fs.DurationVar(&config.FileTimeout, "file-timeout", 0, "Give up reading a single file after this duration (e.g., 10s)")
+
+	fs.StringVar(&config.FilesFrom, "files-from", "", "Count only the paths listed in this file, or - for standard input (newline or NUL separated)")
+	fs.StringVar(&config.ChangedSince, "changed-since", "", "Count only the files changed since this git revision, including untracked files")
+
+	fs.BoolVar(&config.Stdin, "stdin", false, "Count the content of standard input as a single file")
+	fs.StringVar(&config.StdinFilename, "stdin-filename", "", "File name to detect the language of standard input from (implies --stdin)")
+
+	fs.StringVar(&config.Explain, "explain", "", "Explain why a file is counted as a language or skipped")
+
+	fs.BoolVar(&config.ListLanguages, "list-languages", false, "List supported languages with their extensions, filenames and comment syntax")
+
+	fs.BoolVar(&config.TestSplit, "tests", false, "Show production vs test code per language")
+	fs.BoolVar(&config.TestSplit, "t", false, "Show production vs test code per language (shorthand)")
+
+	fs.BoolVar(&config.ByDir, "by-dir", false, "Show statistics as a directory tree")
+	fs.IntVar(&config.DirDepth, "depth", 1, "Maximum directory depth for --by-dir (0 for unlimited)")
+
+	fs.BoolVar(&config.ByProject, "by-project", false, "Show statistics per detected project (go.mod, package.json, ...)")
+
+	fs.BoolVar(&config.ByOwner, "by-owner", false, "Show statistics per owner from the CODEOWNERS file")
+
+	// Quality gates
+	fs.IntVar(&config.MaxFileLines, "max-file-lines", 0, "Fail if a file has more than this many lines")
+	fs.IntVar(&config.MaxTotalCode, "max-total-code", 0, "Fail if the total code lines exceed this number")
+	fs.Float64Var(&config.MinCommentRatio, "min-comment-ratio", 0, "Fail if a language has a lower share of comment lines among comment and code lines (e.g., 0.1)")
+	fs.StringVar(&config.MaxGrowth, "max-growth", "", "Fail if the code lines grew by more than this against --baseline (e.g., 500 or 5%)")
+	fs.StringVar(&config.Baseline, "baseline", "", "JSON report from -f json to measure --max-growth against")
+}
+
+// registerScanFlags defines the flags controlling how a tree is scanned and
+// reported, shared by the main command and its subcommands
+func registerScanFlags(fs *flag.FlagSet, config *Config) {
+	fs.StringVar(&config.Path, "path", ".", "Path to the directory to analyze")
+	fs.StringVar(&config.Path, "p", ".", "Path to the directory to analyze (shorthand)")
+
+	fs.StringVar(&config.ConfigFile, "config", "", "Config file to use instead of the .locc.toml or .locc.yaml found from the path upward")
+
+	fs.IntVar(&config.Workers, "workers", runtime.NumCPU(), "Number of worker goroutines")
+	fs.IntVar(&config.Workers, "w", runtime.NumCPU(), "Number of worker goroutines (shorthand)")
+
+	fs.IntVar(&config.DirWorkers, "dir-workers", runtime.NumCPU(), "Number of directories read concurrently")
+
+	fs.BoolVar(&config.IncludeHidden, "hidden", false, "Include hidden files and directories")
+	fs.BoolVar(&config.IncludeHidden, "H", false, "Include hidden files and directories (shorthand)")
+
+	fs.BoolVar(&config.FollowSymlinks, "follow-symlinks", false, "Follow symlinked directories and files")
+	fs.BoolVar(&config.OneFileSystem, "one-file-system", false, "Do not cross filesystem boundaries")
+
+	fs.StringVar(&config.OutputFormat, "format", "default", "Output format: default, json, ndjson, compact, formatted, prometheus")
+	fs.StringVar(&config.OutputFormat, "f", "default", "Output format (shorthand)")
+
+	fs.BoolVar(&config.ShowErrors, "errors", false, "Show detailed error messages")
+	fs.BoolVar(&config.ShowErrors, "e", false, "Show detailed error messages (shorthand)")
+
+	fs.BoolVar(&config.Verbose, "verbose", false, "Enable verbose output")
+	fs.BoolVar(&config.Verbose, "v", false, "Enable verbose output (shorthand)")
+
+	fs.BoolVar(&config.Quiet, "quiet", false, "Suppress non-essential output")
+	fs.BoolVar(&config.Quiet, "q", false, "Suppress non-essential output (shorthand)")
+
+	// Include and exclude globs relative to the root
+	fs.Var((*listFlag)(&config.Includes), "include", "Comma-separated list of globs relative to the root to count (e.g., \"src/**,cmd/*.go\")")
+	fs.Var((*listFlag)(&config.Excludes), "exclude", "Comma-separated list of directory names or globs relative to the root to exclude")
+	fs.Var((*listFlag)(&config.Excludes), "x", "Comma-separated list of directory names or globs to exclude (shorthand)")
+
+	// Regular expressions may contain commas, so each flag takes a single expression
+	fs.Var((*appendFlag)(&config.IncludeRegex), "include-regex", "Regular expression for paths to count (repeatable)")
+	fs.Var((*appendFlag)(&config.ExcludeRegex), "exclude-regex", "Regular expression for paths to exclude (repeatable)")
+
+	// Custom exclude patterns
+	fs.Var((*listFlag)(&config.ExcludePatterns), "ignore", "Comma-separated list of patterns to exclude files (e.g., \"*_test.go,*.log\")")
+	fs.Var((*listFlag)(&config.ExcludePatterns), "i", "Comma-separated list of patterns to exclude files (shorthand)")
+
+	// Language filters
+	fs.Var((*listFlag)(&config.Languages), "lang", "Comma-separated list of languages to count (e.g., \"Go,TypeScript\")")
+	fs.Var((*listFlag)(&config.ExcludeLangs), "exclude-lang", "Comma-separated list of languages to exclude (e.g., \"Markdown,JSON\")")
+
+	fs.BoolVar(&config.CountOther, "other", false, "Count files without a registered language as plain text under \"Other\"")
+
+	fs.BoolVar(&config.Archives, "archives", false, "Count the files inside zip, jar, tar, tar.gz and tar.bz2 archives")
+
+	// Size and depth limits
+	fs.Var((*sizeFlag)(&config.MaxFileSize), "max-file-size", "Skip files larger than this size (e.g., 1MB, 512K)")
+	fs.Var((*sizeFlag)(&config.MinFileSize), "min-file-size", "Skip files smaller than this size (e.g., 10B)")
+	fs.IntVar(&config.MaxDepth, "max-depth", 0, "Maximum directory depth to scan, 1 for the root only (0 for unlimited)")
+
+	// Custom test patterns
+	fs.Var((*listFlag)(&config.TestPatterns), "test-patterns", "Comma-separated list of glob patterns marking test files (e.g., \"*_it.go,e2e/**\")")
+}
+
+// listFlag is a flag value holding a comma-separated list
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = splitAndTrim(value, ",")
+	return nil
+}
+
+// appendFlag is a flag value collecting one value per occurrence
+type appendFlag []string
+
+func (a *appendFlag) String() string {
+	return strings.Join(*a, " ")
+}
+
+func (a *appendFlag) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
+// sizeFlag is a flag value holding a size in bytes, given with an optional
+// B, K, M or G suffix (powers of 1024)
+type sizeFlag int64
+
+func (s *sizeFlag) String() string {
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *sizeFlag) Set(value string) error {
+	size, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = sizeFlag(size)
+	return nil
+}
+
+// parseSize parses a size such as "512", "10K", "1.5MB" or "2GiB" into bytes
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// newWalkerFromConfig creates a Walker configured from the scan options
+func newWalkerFromConfig(config *Config) (*Walker, error) {
+	walker := NewWalker(config.Path, config.Workers)
+	walker.SetDirWorkers(config.DirWorkers)
+	walker.SetIncludeHidden(config.IncludeHidden)
+	walker.SetFollowSymlinks(config.FollowSymlinks)
+	walker.SetOneFileSystem(config.OneFileSystem)
+	walker.SetFileTimeout(config.FileTimeout)
+	walker.SetFileSizeLimits(config.MinFileSize, config.MaxFileSize)
+	walker.SetMaxDepth(config.MaxDepth)
+	walker.SetCountOther(config.CountOther)
+	walker.SetArchives(config.Archives)
+
+	// Restrict the walk to an explicit list of files
+	if config.FilesFrom != "" && config.ChangedSince != "" {
+		return nil, fmt.Errorf("only one of --files-from and --changed-since can be used")
+	}
+	if config.FilesFrom != "" {
+		files, err := LoadFileList(config.FilesFrom)
+		if err != nil {
+			return nil, err
+		}
+		walker.SetFiles(files)
+	}
+	if config.ChangedSince != "" {
+		files, err := GitChangedFiles(config.Path, config.ChangedSince)
+		if err != nil {
+			return nil, err
+		}
+		walker.SetFiles(files)
+	}
+
+	// Add include and exclude filters
+	filter, err := newPathFilterFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	walker.SetPathFilter(filter)
+
+	// Add language filters
+	langFilter, err := NewLanguageFilter(config.Languages, config.ExcludeLangs)
+	if err != nil {
+		return nil, err
+	}
+	// Warn when --lang drops the files --other was asked to count
+	if included, _ := NewLanguageFilter(config.Languages, nil); config.CountOther && len(config.Languages) > 0 && !included.Match(otherLanguage.Name) {
+		LogWarn("--lang %s leaves out the files counted by --other; add %s to count them",
+			strings.Join(config.Languages, ","), otherLanguage.Name)
+	}
+	walker.SetLanguageFilter(langFilter)
+	walker.SetLanguageOverrides(config.LangOverrides)
+
+	// Add exclude patterns
+	for _, pattern := range config.ExcludePatterns {
+		walker.AddExcludePattern(pattern)
+	}
+
+	// Add test patterns
+	for _, pattern := range config.TestPatterns {
+		walker.AddTestPattern(pattern)
+	}
+
+	return walker, nil
+}
+
+// newPathFilterFromConfig compiles the include and exclude options into a PathFilter
+func newPathFilterFromConfig(config *Config) (*PathFilter, error) {
+	filter := NewPathFilter()
+	for _, pattern := range config.Includes {
+		if err := filter.AddInclude(pattern); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range config.Excludes {
+		if err := filter.AddExclude(pattern); err != nil {
+			return nil, err
+		}
+	}
+	for _, expr := range config.IncludeRegex {
+		if err := filter.AddIncludeRegex(expr); err != nil {
+			return nil, err
+		}
+	}
+	for _, expr := range config.ExcludeRegex {
+		if err := filter.AddExcludeRegex(expr); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
 }
 
 func printUsage() {
@@ -228,14 +890,62 @@ func printUsage() {
 
 Usage:
   %s [options] [path]
+  %s authors [options] [path]
+  %s snapshot [options] [path]
+  %s compare [options] <snapshot> [path | snapshot]
+  %s compare-dirs [options] <old> <new>
+  %s serve [options] --allow <dirs>
+
+Commands:
+  authors                 Attribute code and comment lines to authors using git blame
+  snapshot                Save per-file statistics to a JSON snapshot
+  compare                 Compare a directory or snapshot against a saved snapshot
+  compare-dirs            Compare two directories file by file
+  serve                   Serve counts of allowed directories over HTTP as a JSON API
 
 Options:
   -p, --path <path>       Path to the directory to analyze (default: current directory)
+  --config <file>         Config file to use instead of the .locc.toml or .locc.yaml found from the path upward
   -w, --workers <n>       Number of worker goroutines (default: number of CPUs)
+  --dir-workers <n>       Number of directories read concurrently (default: number of CPUs)
   -H, --hidden            Include hidden files and directories
-  -f, --format <format>   Output format: default, json, compact, formatted
-  -x, --exclude <dirs>    Comma-separated list of directories to exclude
+  -f, --format <format>   Output format: default, json, ndjson, compact, formatted, prometheus
+  --follow-symlinks       Follow symlinked directories and files
+  --one-file-system       Do not cross filesystem boundaries
+  --include <globs>       Comma-separated list of globs relative to the root to count
+  -x, --exclude <globs>   Comma-separated list of directory names or globs relative to the root to exclude
+  --include-regex <re>    Regular expression for paths to count (repeatable)
+  --exclude-regex <re>    Regular expression for paths to exclude (repeatable)
+  --lang <languages>      Comma-separated list of languages to count (e.g., "Go,TypeScript")
+  --exclude-lang <langs>  Comma-separated list of languages to exclude (e.g., "Markdown,JSON")
+  --other                 Count files without a registered language as plain text under "Other"
+  --archives              Count the files inside zip, jar, tar, tar.gz and tar.bz2 archives
+  --max-file-size <size>  Skip files larger than this size (e.g., 1MB, 512K)
+  --min-file-size <size>  Skip files smaller than this size (e.g., 10B)
+  --max-depth <n>         Maximum directory depth to scan, 1 for the root only (default: unlimited)
+  --files-from <file|->   Count only the listed paths, relative to the scanned directory
+                          (newline or NUL separated, - for standard input)
+  --changed-since <rev>   Count only the files changed since a git revision, including
+                          staged and untracked files
+  --stdin                 Count the content of standard input, in the language given by --lang
+  --stdin-filename <name> Count standard input, detecting its language from this file name
+  --explain <file>        Explain why a file, relative to the path, is counted as a language or skipped, then exit
+  --list-languages        List supported languages with their extensions, filenames and comments
   -i, --ignore <patterns> Comma-separated list of patterns to exclude files
+  --timeout <duration>    Stop the scan after this duration and report partial results
+  --file-timeout <dur>    Give up reading a single file after this duration
+  -t, --tests             Show production vs test code per language
+  --test-patterns <globs> Comma-separated list of extra glob patterns marking test files
+  --by-dir                Show statistics as a directory tree
+  --depth <n>             Maximum directory depth for --by-dir (default: 1, 0 for unlimited)
+  --by-project            Show statistics per detected project (go.mod, package.json, ...)
+  --by-owner              Show statistics per owner from the CODEOWNERS file
+  --max-file-lines <n>    Fail if a file has more than n lines
+  --max-total-code <n>    Fail if the total code lines exceed n
+  --min-comment-ratio <r> Fail if a language's comment lines are less than this share of
+                          its comment and code lines (e.g., 0.1)
+  --max-growth <n|n%%>     Fail if the code lines grew by more than this against --baseline
+  --baseline <file>       JSON report from -f json to measure --max-growth against
   -e, --errors            Show detailed error messages
   -v, --verbose           Enable verbose output
   -q, --quiet             Suppress non-essential output
@@ -249,15 +959,21 @@ Examples:
   %s -w 8 -H .            Use 8 workers and include hidden files
   %s -x "test,docs" .     Exclude test and docs directories
   %s -i "users_*.go,*log" . Exclude files matching patterns
+  %s -t .                 Show production vs test code
+  %s --by-dir --depth 2 . Show a directory tree two levels deep
+  %s --changed-since origin/main
+                          Count the files changed on the current branch
+  %s --stdin --lang Go < main.go
+                          Count a buffer piped in by an editor
+  %s --max-file-lines 1000 --min-comment-ratio 0.1 .
+                          Fail with exit code 3 on oversized files or sparse comments
+  %s -f prometheus . > /var/lib/node_exporter/locc.prom
+                          Export metrics for node_exporter's textfile collector
 
 Supported Languages:
-  Go, JavaScript, TypeScript, Python, Java, C, C++, C#, Ruby, PHP,
-  Swift, Kotlin, Rust, Scala, HTML, CSS, SCSS, SQL, Shell, YAML,
-  JSON, Markdown, XML, Vue, Svelte, Lua, R, Perl, Elixir, Erlang,
-  Haskell, Clojure, TOML, INI, Terraform, Protocol Buffers, GraphQL,
-  Assembly
+  Run %s --list-languages for every language with its extensions and file names
 
-`, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName)
+`, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName)
 }
 
 func splitAndTrim(s string, sep string) []string {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -109,7 +110,10 @@ func TestParseFlags(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			os.Args = tt.args
 			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-			config := parseFlags()
+			config, err := parseFlags()
+			if err != nil {
+				t.Fatalf("parseFlags() error: %v", err)
+			}
 
 			if config.Path != tt.wantPath {
 				t.Errorf("Path = %q, want %q", config.Path, tt.wantPath)
@@ -123,8 +127,8 @@ func TestParseFlags(t *testing.T) {
 			if tt.wantFormat != "" && config.OutputFormat != tt.wantFormat {
 				t.Errorf("OutputFormat = %q, want %q", config.OutputFormat, tt.wantFormat)
 			}
-			if !reflect.DeepEqual(config.ExcludeDirs, tt.wantExcludes) {
-				t.Errorf("ExcludeDirs = %v, want %v", config.ExcludeDirs, tt.wantExcludes)
+			if !reflect.DeepEqual(config.Excludes, tt.wantExcludes) {
+				t.Errorf("Excludes = %v, want %v", config.Excludes, tt.wantExcludes)
 			}
 		})
 	}
@@ -198,6 +202,94 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestStdinLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		wantLang string
+		wantPath string
+		wantErr  bool
+	}{
+		{"lang", &Config{Languages: []string{"go"}}, "Go", stdinName, false},
+		{"filename", &Config{StdinFilename: "src/app.py"}, "Python", "src/app.py", false},
+		{"filename wins over lang", &Config{StdinFilename: "app.py", Languages: []string{"Go"}}, "Python", "app.py", false},
+		{"lang for unknown filename", &Config{StdinFilename: "app.tmpl", Languages: []string{"HTML"}}, "HTML", "app.tmpl", false},
+		{"other", &Config{StdinFilename: "notes.zzz", CountOther: true}, "Other", "notes.zzz", false},
+		{"lang other", &Config{Languages: []string{"other"}}, "Other", stdinName, false},
+		{"unknown filename", &Config{StdinFilename: "notes.zzz"}, "", "", true},
+		{"several languages", &Config{Languages: []string{"Go", "Python"}}, "", "", true},
+		{"unknown lang", &Config{Languages: []string{"Nope"}}, "", "", true},
+		{"nothing", &Config{}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, path, err := stdinLanguage(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("stdinLanguage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if lang.Name != tt.wantLang || path != tt.wantPath {
+				t.Errorf("stdinLanguage() = %s, %q, want %s, %q", lang.Name, path, tt.wantLang, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestRunSingleFileTestClassification(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir := filepath.Join(tmpDir, "testdata")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "fixture.go"), []byte("package fixture\n"), 0644)
+
+	// A file is classified the same whether it is scanned alone or with its directory
+	run := func(path string) string {
+		return captureStdout(func() {
+			if err := Run(&Config{Path: path, OutputFormat: "json", TestSplit: true, Quiet: true}); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+		})
+	}
+	if file, dir := run(filepath.Join(dir, "fixture.go")), run(dir); file != dir {
+		t.Errorf("single file output differs from directory output:\n%s\n---\n%s", file, dir)
+	}
+}
+
+func TestRunSingleFileLanguage(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, ".travis.yml")
+	os.WriteFile(file, []byte("language: go\n"), 0644)
+
+	// The hidden file name wins over the extension, as in a directory scan
+	output := captureStdout(func() {
+		if err := Run(&Config{Path: file, OutputFormat: "json", Quiet: true}); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	})
+	if !strings.Contains(output, `"Travis CI"`) {
+		t.Errorf("expected .travis.yml to be counted as Travis CI, got:\n%s", output)
+	}
+}
+
+func TestRunExplainRelative(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "src", "a.go"), []byte("package a\n"), 0644)
+
+	var err error
+	output := captureStdout(func() {
+		err = Run(&Config{Path: tmpDir, Explain: filepath.Join("src", "a.go")})
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(output, "counted as Go") {
+		t.Errorf("expected src/a.go to be explained relative to the scanned directory, got %q", output)
+	}
+}
+
 func TestPrintUsage(t *testing.T) {
 	output := captureStdout(func() {
 		printUsage()
@@ -206,3 +298,86 @@ func TestPrintUsage(t *testing.T) {
 		t.Errorf("printUsage output missing 'Usage:'")
 	}
 }
+
+func TestRunDeterministicOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "main-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	generateTree(t, tmpDir, 300)
+
+	// Languages and directories with identical counts exercise the tie-breakers
+	for _, name := range []string{"a.py", "b.rb", "c.js", "d.ts", "e.lua"} {
+		for _, dir := range []string{"x", "y", "z"} {
+			os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+			os.WriteFile(filepath.Join(tmpDir, dir, name), []byte("x = 1\n"), 0644)
+		}
+	}
+	os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example\n"), 0644)
+
+	// Dangling symlinks produce errors in completion order
+	for i := 0; i < 5; i++ {
+		os.Symlink(filepath.Join(tmpDir, "missing"), filepath.Join(tmpDir, fmt.Sprintf("broken%d.go", i)))
+	}
+
+	configs := []*Config{
+		{OutputFormat: "default", ByDir: true, DirDepth: 0, ByProject: true, TestSplit: true},
+		{OutputFormat: "json"},
+		{OutputFormat: "json", ByDir: true, DirDepth: 0},
+	}
+
+	for _, config := range configs {
+		config.Path = tmpDir
+		config.Workers = 16
+		config.DirWorkers = 16
+		config.FollowSymlinks = true
+		config.ShowErrors = true
+		config.Quiet = true
+
+		var first string
+		for i := 0; i < 10; i++ {
+			output := captureStdout(func() {
+				if err := Run(config); err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			})
+			if i == 0 {
+				first = output
+			} else if output != first {
+				t.Fatalf("format %q: run %d output differs from first run:\n%s\n---\n%s", config.OutputFormat, i, first, output)
+			}
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"10B", 10, false},
+		{"10K", 10 << 10, false},
+		{"10kb", 10 << 10, false},
+		{"1.5MB", 3 << 19, false},
+		{"2GiB", 2 << 30, false},
+		{" 3 M ", 3 << 20, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"-1K", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSize(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSize(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
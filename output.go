@@ -2,8 +2,13 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -89,7 +94,7 @@ func printFooter(processedFiles, skippedFiles, errorCount int) {
 	fmt.Println()
 }
 
-// sortLanguagesByCode sorts languages by code lines in descending order
+// sortLanguagesByCode sorts languages by code lines in descending order, then by name
 func sortLanguagesByCode(langStats map[string]*LanguageStats) []string {
 	langs := make([]string, 0, len(langStats))
 	for lang := range langStats {
@@ -97,7 +102,11 @@ func sortLanguagesByCode(langStats map[string]*LanguageStats) []string {
 	}
 
 	sort.Slice(langs, func(i, j int) bool {
-		return langStats[langs[i]].CodeLines > langStats[langs[j]].CodeLines
+		a, b := langStats[langs[i]], langStats[langs[j]]
+		if a.CodeLines != b.CodeLines {
+			return a.CodeLines > b.CodeLines
+		}
+		return langs[i] < langs[j]
 	})
 
 	return langs
@@ -105,19 +114,24 @@ func sortLanguagesByCode(langStats map[string]*LanguageStats) []string {
 
 // PrintErrors prints the list of errors encountered
 func PrintErrors(errors []error) {
+	WriteErrors(os.Stdout, errors)
+}
+
+// WriteErrors writes the list of errors encountered to w
+func WriteErrors(w io.Writer, errors []error) {
 	if len(errors) == 0 {
 		return
 	}
 
-	fmt.Println("\nErrors encountered:")
+	fmt.Fprintln(w, "\nErrors encountered:")
 	for i, err := range errors {
 		if i >= 10 {
-			fmt.Printf("  ... and %d more errors\n", len(errors)-10)
+			fmt.Fprintf(w, "  ... and %d more errors\n", len(errors)-10)
 			break
 		}
-		fmt.Printf("  - %v\n", err)
+		fmt.Fprintf(w, "  - %v\n", err)
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 // PrintCompact prints a compact summary
@@ -126,10 +140,30 @@ func PrintCompact(total *LanguageStats) {
 		total.FileCount, total.BlankLines, total.CommentLines, total.CodeLines, total.TotalLines)
 }
 
+// RunSummary holds metadata about a run reported alongside the statistics
+type RunSummary struct {
+	ProcessedFiles int
+	SkippedFiles   int
+	ErrorCount     int
+	Partial        bool
+	Skipped        SkipStats
+	Unrecognized   []*UnrecognizedStats
+}
+
 // PrintJSON prints results in JSON format
 func PrintJSON(langStats map[string]*LanguageStats, total *LanguageStats) {
-	fmt.Println("{")
-	fmt.Println("  \"languages\": {")
+	PrintJSONSummary(langStats, total, nil)
+}
+
+// PrintJSONSummary prints results in JSON format, including the run summary if it is non-nil
+func PrintJSONSummary(langStats map[string]*LanguageStats, total *LanguageStats, summary *RunSummary) {
+	WriteJSONSummary(os.Stdout, langStats, total, summary)
+}
+
+// WriteJSONSummary writes results in JSON format like PrintJSONSummary
+func WriteJSONSummary(w io.Writer, langStats map[string]*LanguageStats, total *LanguageStats, summary *RunSummary) {
+	fmt.Fprintln(w, "{")
+	fmt.Fprintln(w, "  \"languages\": {")
 
 	sortedLangs := sortLanguagesByCode(langStats)
 	for i, lang := range sortedLangs {
@@ -138,14 +172,165 @@ func PrintJSON(langStats map[string]*LanguageStats, total *LanguageStats) {
 		if i == len(sortedLangs)-1 {
 			comma = ""
 		}
-		fmt.Printf("    \"%s\": {\"files\": %d, \"blank\": %d, \"comment\": %d, \"code\": %d, \"total\": %d}%s\n",
-			stats.Language, stats.FileCount, stats.BlankLines, stats.CommentLines, stats.CodeLines, stats.TotalLines, comma)
+		fmt.Fprintf(w, "    \"%s\": %s%s\n", stats.Language, formatStatsJSON(stats), comma)
 	}
 
-	fmt.Println("  },")
-	fmt.Printf("  \"total\": {\"files\": %d, \"blank\": %d, \"comment\": %d, \"code\": %d, \"total\": %d}\n",
-		total.FileCount, total.BlankLines, total.CommentLines, total.CodeLines, total.TotalLines)
-	fmt.Println("}")
+	fmt.Fprintln(w, "  },")
+	if summary == nil {
+		fmt.Fprintf(w, "  \"total\": %s\n", formatStatsJSON(total))
+	} else {
+		fmt.Fprintf(w, "  \"total\": %s,\n", formatStatsJSON(total))
+		fmt.Fprintf(w, "  \"summary\": {\"files_processed\": %d, \"files_skipped\": %d, \"errors\": %d, \"partial\": %t, \"skipped_by_reason\": %s, \"unrecognized\": %s}\n",
+			summary.ProcessedFiles, summary.SkippedFiles, summary.ErrorCount, summary.Partial, formatSkipsJSON(summary.Skipped), formatUnrecognizedJSON(summary.Unrecognized))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// PrintFileNDJSON prints a single file's statistics as one NDJSON record,
+// with the path relative to rootPath
+func PrintFileNDJSON(fs *FileStats, rootPath string) {
+	WriteFileNDJSON(os.Stdout, fs, rootPath)
+}
+
+// WriteFileNDJSON writes a single file's statistics like PrintFileNDJSON
+func WriteFileNDJSON(w io.Writer, fs *FileStats, rootPath string) {
+	rel, err := filepath.Rel(rootPath, fs.FilePath)
+	if err != nil {
+		rel = fs.FilePath
+	}
+	fmt.Fprintf(w, "{\"type\": \"file\", \"path\": %s, \"language\": %s, \"blank\": %d, \"comment\": %d, \"code\": %d, \"total\": %d}\n",
+		jsonString(filepath.ToSlash(rel)), jsonString(fs.Language), fs.BlankLines, fs.CommentLines, fs.CodeLines, fs.TotalLines)
+}
+
+// PrintErrorNDJSON prints an error as one NDJSON record
+func PrintErrorNDJSON(err error) {
+	WriteErrorNDJSON(os.Stdout, err)
+}
+
+// WriteErrorNDJSON writes an error as one NDJSON record
+func WriteErrorNDJSON(w io.Writer, err error) {
+	fmt.Fprintf(w, "{\"type\": \"error\", \"message\": %s}\n", jsonString(err.Error()))
+}
+
+// PrintSummaryNDJSON prints the closing NDJSON record with per-language totals
+// and the run summary
+func PrintSummaryNDJSON(langStats map[string]*LanguageStats, total *LanguageStats, summary *RunSummary) {
+	WriteSummaryNDJSON(os.Stdout, langStats, total, summary)
+}
+
+// WriteSummaryNDJSON writes the closing NDJSON record like PrintSummaryNDJSON
+func WriteSummaryNDJSON(w io.Writer, langStats map[string]*LanguageStats, total *LanguageStats, summary *RunSummary) {
+	var languages strings.Builder
+	for i, lang := range sortLanguagesByCode(langStats) {
+		if i > 0 {
+			languages.WriteString(", ")
+		}
+		fmt.Fprintf(&languages, "%s: %s", jsonString(lang), formatStatsJSON(langStats[lang]))
+	}
+
+	fmt.Fprintf(w, "{\"type\": \"summary\", \"languages\": {%s}, \"total\": %s, \"files_processed\": %d, \"files_skipped\": %d, \"errors\": %d, \"partial\": %t, \"skipped_by_reason\": %s, \"unrecognized\": %s}\n",
+		languages.String(), formatStatsJSON(total), summary.ProcessedFiles, summary.SkippedFiles, summary.ErrorCount, summary.Partial, formatSkipsJSON(summary.Skipped), formatUnrecognizedJSON(summary.Unrecognized))
+}
+
+// maxSkipExtensions is the number of extensions listed per skip reason in tables
+const maxSkipExtensions = 5
+
+// PrintSkipBreakdown prints the number of skipped files per reason with the
+// most common extensions
+func PrintSkipBreakdown(skips SkipStats) {
+	if len(skips) == 0 {
+		return
+	}
+
+	fmt.Println("Skipped files:")
+	for _, reason := range skips.Reasons() {
+		exts := skips.Extensions(reason)
+		parts := make([]string, 0, maxSkipExtensions+1)
+		for i, ext := range exts {
+			if i == maxSkipExtensions {
+				parts = append(parts, fmt.Sprintf("%d more", len(exts)-maxSkipExtensions))
+				break
+			}
+			parts = append(parts, fmt.Sprintf("%s %d", ext, skips[reason][ext]))
+		}
+		fmt.Printf("  %-17s %*d   %s\n", string(reason)+":", colFiles-4, skips.Count(reason), strings.Join(parts, ", "))
+	}
+	fmt.Println()
+}
+
+// formatSkipsJSON formats skipped file counts per reason and extension as a JSON object
+func formatSkipsJSON(skips SkipStats) string {
+	reasons := skips.Reasons()
+	parts := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		exts := skips.Extensions(reason)
+		extParts := make([]string, 0, len(exts))
+		for _, ext := range exts {
+			extParts = append(extParts, fmt.Sprintf("%s: %d", jsonString(ext), skips[reason][ext]))
+		}
+		parts = append(parts, fmt.Sprintf("%s: {\"files\": %d, \"extensions\": {%s}}",
+			jsonString(string(reason)), skips.Count(reason), strings.Join(extParts, ", ")))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// maxUnrecognized is the number of unrecognized extensions listed in tables
+const maxUnrecognized = 20
+
+// PrintUnrecognized prints the extensions and file names without a registered
+// language, with their file counts and total size
+func PrintUnrecognized(unrecognized []*UnrecognizedStats, formatted bool) {
+	if len(unrecognized) == 0 {
+		return
+	}
+
+	format := func(n int64) string {
+		if formatted {
+			return FormatNumber(int(n))
+		}
+		return strconv.FormatInt(n, 10)
+	}
+
+	fmt.Println("Unrecognized:")
+	fmt.Printf("  %-*s %*s %*s\n", colLanguage, "Extension / Name", colFiles, "Files", colTotal, "Bytes")
+	for i, stats := range unrecognized {
+		if i == maxUnrecognized {
+			fmt.Printf("  ... and %d more\n", len(unrecognized)-maxUnrecognized)
+			break
+		}
+		fmt.Printf("  %-*s %*s %*s\n", colLanguage, stats.Name, colFiles, format(int64(stats.Files)), colTotal, format(stats.Bytes))
+	}
+	fmt.Println()
+}
+
+// formatUnrecognizedJSON formats unrecognized extensions and file names as a JSON object
+func formatUnrecognizedJSON(unrecognized []*UnrecognizedStats) string {
+	parts := make([]string, 0, len(unrecognized))
+	for _, stats := range unrecognized {
+		parts = append(parts, fmt.Sprintf("%s: {\"files\": %d, \"bytes\": %d}", jsonString(stats.Name), stats.Files, stats.Bytes))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// PrintExplanation prints why a file is counted as a language or skipped
+func PrintExplanation(e *Explanation) {
+	if !e.Counted() {
+		fmt.Printf("%s: skipped (%s)\n", e.Path, e.Reason)
+		fmt.Printf("  %s\n", e.Detail)
+		return
+	}
+
+	kind := "production code"
+	if e.IsTest {
+		kind = "test code"
+	}
+	fmt.Printf("%s: counted as %s\n", e.Path, e.Language.Name)
+	fmt.Printf("  matched by %s, classified as %s\n", e.Detail, kind)
+}
+
+// PrintPartialNotice prints a notice that the results only cover part of the tree
+func PrintPartialNotice(reason error) {
+	fmt.Printf("Partial results: scan stopped early (%v); counts cover only the files processed so far.\n\n", reason)
 }
 
 // PrintByFiles prints results sorted by file count
@@ -159,7 +344,11 @@ func PrintByFiles(langStats map[string]*LanguageStats, total *LanguageStats, pro
 		langs = append(langs, lang)
 	}
 	sort.Slice(langs, func(i, j int) bool {
-		return langStats[langs[i]].FileCount > langStats[langs[j]].FileCount
+		a, b := langStats[langs[i]], langStats[langs[j]]
+		if a.FileCount != b.FileCount {
+			return a.FileCount > b.FileCount
+		}
+		return langs[i] < langs[j]
 	})
 
 	// Print each language row
@@ -243,3 +432,214 @@ func PrintResultsFormatted(langStats map[string]*LanguageStats, total *LanguageS
 
 	printFooter(processedFiles, skippedFiles, errorCount)
 }
+
+// PrintTestSplit prints production and test code lines per language
+func PrintTestSplit(splitStats map[string]*TestSplitStats, total *TestSplitStats) {
+	fmt.Println()
+	printSeparator()
+	fmt.Printf("%-*s %*s %*s %*s %*s %*s\n",
+		colLanguage, "Language",
+		colFiles, "Prod Files",
+		colBlank, "Prod Code",
+		colComment, "Test Files",
+		colCode, "Test Code",
+		colTotal, "Test Ratio")
+	printSeparator()
+
+	// Sort languages by total code lines (descending)
+	langs := make([]string, 0, len(splitStats))
+	for lang := range splitStats {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		a, b := splitStats[langs[i]], splitStats[langs[j]]
+		codeA := a.Production.CodeLines + a.Test.CodeLines
+		codeB := b.Production.CodeLines + b.Test.CodeLines
+		if codeA != codeB {
+			return codeA > codeB
+		}
+		return langs[i] < langs[j]
+	})
+
+	for _, lang := range langs {
+		printTestSplitRow(splitStats[lang])
+	}
+
+	printSeparator()
+	printTestSplitRow(total)
+	printSeparator()
+	fmt.Println()
+}
+
+// printTestSplitRow prints a single row of the production/test table
+func printTestSplitRow(stats *TestSplitStats) {
+	language := stats.Language
+	if len(language) > colLanguage {
+		language = language[:colLanguage-3] + "..."
+	}
+
+	fmt.Printf("%-*s %*d %*d %*d %*d %*.2f\n",
+		colLanguage, language,
+		colFiles, stats.Production.FileCount,
+		colBlank, stats.Production.CodeLines,
+		colComment, stats.Test.FileCount,
+		colCode, stats.Test.CodeLines,
+		colTotal, stats.TestRatio())
+}
+
+// PrintTestSplitJSON prints production and test statistics in JSON format
+func PrintTestSplitJSON(splitStats map[string]*TestSplitStats, total *TestSplitStats) {
+	fmt.Println("{")
+	fmt.Println("  \"languages\": {")
+
+	langs := make([]string, 0, len(splitStats))
+	for lang := range splitStats {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	for i, lang := range langs {
+		comma := ","
+		if i == len(langs)-1 {
+			comma = ""
+		}
+		fmt.Printf("    \"%s\": %s%s\n", lang, formatTestSplitJSON(splitStats[lang]), comma)
+	}
+
+	fmt.Println("  },")
+	fmt.Printf("  \"total\": %s\n", formatTestSplitJSON(total))
+	fmt.Println("}")
+}
+
+// formatTestSplitJSON formats production and test statistics as a JSON object
+func formatTestSplitJSON(stats *TestSplitStats) string {
+	return fmt.Sprintf("{\"production\": %s, \"test\": %s, \"test_ratio\": %.4f}",
+		formatStatsJSON(&stats.Production), formatStatsJSON(&stats.Test), stats.TestRatio())
+}
+
+// formatStatsJSON formats aggregated statistics as a JSON object
+func formatStatsJSON(stats *LanguageStats) string {
+	return fmt.Sprintf("{\"files\": %d, \"blank\": %d, \"comment\": %d, \"code\": %d, \"total\": %d}",
+		stats.FileCount, stats.BlankLines, stats.CommentLines, stats.CodeLines, stats.TotalLines)
+}
+
+// commentSyntax describes a language's comment markers, e.g. "// /* */"
+func commentSyntax(li *LanguageInfo) string {
+	var parts []string
+	if li.SingleLineComment != "" {
+		parts = append(parts, li.SingleLineComment)
+	}
+	if li.MultiLineStart != "" {
+		parts = append(parts, li.MultiLineStart+" "+li.MultiLineEnd)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, "  ")
+}
+
+// PrintLanguageList prints every supported language with how it is detected
+func PrintLanguageList(langs []*LanguageInfo) {
+	fmt.Printf("%-*s %-*s %s\n", colLanguage, "Language", colComment+colCode, "Comments", "Extensions / Filenames")
+	printSeparator()
+	for _, li := range langs {
+		matches := append(append([]string(nil), li.Extensions...), li.Filenames...)
+		fmt.Printf("%-*s %-*s %s\n", colLanguage, li.Name, colComment+colCode, commentSyntax(li), strings.Join(matches, ", "))
+	}
+}
+
+// PrintLanguageListJSON prints every supported language in JSON format
+func PrintLanguageListJSON(langs []*LanguageInfo) {
+	WriteLanguageListJSON(os.Stdout, langs)
+}
+
+// WriteLanguageListJSON writes every supported language in JSON format
+func WriteLanguageListJSON(w io.Writer, langs []*LanguageInfo) {
+	fmt.Fprintln(w, "[")
+	for i, li := range langs {
+		comma := ","
+		if i == len(langs)-1 {
+			comma = ""
+		}
+		fmt.Fprintf(w, "  {\"name\": %s, \"extensions\": %s, \"filenames\": %s, \"line_comment\": %s, \"block_comment_start\": %s, \"block_comment_end\": %s}%s\n",
+			jsonString(li.Name), jsonStringList(li.Extensions), jsonStringList(li.Filenames),
+			jsonString(li.SingleLineComment), jsonString(li.MultiLineStart), jsonString(li.MultiLineEnd), comma)
+	}
+	fmt.Fprintln(w, "]")
+}
+
+// jsonStringList returns strs encoded as a JSON array of strings
+func jsonStringList(strs []string) string {
+	quoted := make([]string, len(strs))
+	for i, s := range strs {
+		quoted[i] = jsonString(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// PrintPrometheus prints results in the Prometheus text exposition format
+func PrintPrometheus(path string, langStats map[string]*LanguageStats, summary *RunSummary, elapsed time.Duration) {
+	WritePrometheus(os.Stdout, path, langStats, summary, elapsed, time.Now())
+}
+
+// WritePrometheus writes per-language line counts and run metadata as gauges
+// in the Prometheus text exposition format, as read by node_exporter's textfile
+// collector. Every series has a path label so that scans of several trees can
+// be collected side by side.
+func WritePrometheus(w io.Writer, path string, langStats map[string]*LanguageStats, summary *RunSummary, elapsed time.Duration, finished time.Time) {
+	langs := make([]string, 0, len(langStats))
+	for lang := range langStats {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	pathLabel := "path=" + promLabelValue(path)
+	perLanguage := []struct {
+		name  string
+		help  string
+		value func(*LanguageStats) int
+	}{
+		{"locc_files", "Number of files counted per language.", func(s *LanguageStats) int { return s.FileCount }},
+		{"locc_code_lines", "Number of code lines per language.", func(s *LanguageStats) int { return s.CodeLines }},
+		{"locc_comment_lines", "Number of comment lines per language.", func(s *LanguageStats) int { return s.CommentLines }},
+		{"locc_blank_lines", "Number of blank lines per language.", func(s *LanguageStats) int { return s.BlankLines }},
+	}
+	for _, m := range perLanguage {
+		writePromHeader(w, m.name, m.help)
+		for _, lang := range langs {
+			fmt.Fprintf(w, "%s{%s,language=%s} %d\n", m.name, pathLabel, promLabelValue(lang), m.value(langStats[lang]))
+		}
+	}
+
+	partial := 0
+	if summary.Partial {
+		partial = 1
+	}
+	writePromHeader(w, "locc_files_processed", "Number of files counted.")
+	fmt.Fprintf(w, "locc_files_processed{%s} %d\n", pathLabel, summary.ProcessedFiles)
+	writePromHeader(w, "locc_files_skipped", "Number of files skipped.")
+	fmt.Fprintf(w, "locc_files_skipped{%s} %d\n", pathLabel, summary.SkippedFiles)
+	writePromHeader(w, "locc_files_skipped_by_reason", "Number of files skipped per reason.")
+	for _, reason := range summary.Skipped.Reasons() {
+		fmt.Fprintf(w, "locc_files_skipped_by_reason{%s,reason=%s} %d\n", pathLabel, promLabelValue(string(reason)), summary.Skipped.Count(reason))
+	}
+	writePromHeader(w, "locc_errors", "Number of files that could not be read.")
+	fmt.Fprintf(w, "locc_errors{%s} %d\n", pathLabel, summary.ErrorCount)
+	writePromHeader(w, "locc_scan_partial", "Whether the scan was interrupted before all files were counted (1) or not (0).")
+	fmt.Fprintf(w, "locc_scan_partial{%s} %d\n", pathLabel, partial)
+	writePromHeader(w, "locc_scan_duration_seconds", "Duration of the scan in seconds.")
+	fmt.Fprintf(w, "locc_scan_duration_seconds{%s} %s\n", pathLabel, strconv.FormatFloat(elapsed.Seconds(), 'f', -1, 64))
+	writePromHeader(w, "locc_scan_timestamp_seconds", "Unix time at which the scan finished.")
+	fmt.Fprintf(w, "locc_scan_timestamp_seconds{%s} %d\n", pathLabel, finished.Unix())
+}
+
+// writePromHeader writes the HELP and TYPE lines of a gauge
+func writePromHeader(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// promLabelValue quotes a label value, escaping backslashes, double quotes and
+// newlines
+func promLabelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPrintResults(t *testing.T) {
@@ -111,3 +114,145 @@ func TestFormatNumber(t *testing.T) {
 		}
 	}
 }
+
+func TestPrintNDJSON(t *testing.T) {
+	fs := &FileStats{
+		FilePath:     filepath.Join("root", "cmd", "main.go"),
+		Language:     "Go",
+		BlankLines:   1,
+		CommentLines: 2,
+		CodeLines:    3,
+		TotalLines:   6,
+	}
+	langStats := AggregateStats([]*FileStats{fs})
+	total := TotalStats(langStats)
+
+	output := captureStdout(func() {
+		PrintFileNDJSON(fs, "root")
+		PrintErrorNDJSON(errors.New("boom"))
+		PrintSummaryNDJSON(langStats, total, &RunSummary{ProcessedFiles: 1, ErrorCount: 1})
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 records, got %d: %s", len(lines), output)
+	}
+
+	wantTypes := []string{"file", "error", "summary"}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("record %d is not valid JSON: %v\n%s", i, err, line)
+		}
+		if record["type"] != wantTypes[i] {
+			t.Errorf("record %d type = %v, want %s", i, record["type"], wantTypes[i])
+		}
+	}
+
+	if !strings.Contains(lines[0], `"path": "cmd/main.go"`) {
+		t.Errorf("file record missing relative path: %s", lines[0])
+	}
+	if !strings.Contains(lines[2], `"files_processed": 1`) || !strings.Contains(lines[2], `"Go": {"files": 1`) {
+		t.Errorf("summary record missing expected content: %s", lines[2])
+	}
+}
+
+func TestPrintSkipBreakdown(t *testing.T) {
+	skips := make(SkipStats)
+	for _, path := range []string{"a.png", "b.png", "c.jpg", "d.xyz", "e.1", "f.2", "g.3", "h.4", "i.5", "j.6"} {
+		if strings.HasSuffix(path, ".png") || strings.HasSuffix(path, ".jpg") {
+			skips.add(SkipBinary, path)
+		} else {
+			skips.add(SkipUnsupported, path)
+		}
+	}
+
+	output := captureStdout(func() {
+		PrintSkipBreakdown(skips)
+	})
+	if !strings.Contains(output, "binary:") || !strings.Contains(output, ".png 2, .jpg 1") {
+		t.Errorf("Output missing binary breakdown: %s", output)
+	}
+	if !strings.Contains(output, "unsupported:") || !strings.Contains(output, "2 more") {
+		t.Errorf("Output missing unsupported breakdown: %s", output)
+	}
+	if strings.Index(output, "unsupported:") > strings.Index(output, "binary:") {
+		t.Errorf("expected reasons sorted by count: %s", output)
+	}
+
+	json := formatSkipsJSON(skips)
+	if !strings.HasPrefix(json, `{"unsupported": {"files": 7, "extensions": {`) {
+		t.Errorf("unexpected JSON: %s", json)
+	}
+}
+
+func TestPrintUnrecognized(t *testing.T) {
+	unrecognized := []*UnrecognizedStats{
+		{Name: ".lockb", Files: 3, Bytes: 123456},
+		{Name: "Brewfile", Files: 1, Bytes: 42},
+	}
+
+	output := captureStdout(func() {
+		PrintUnrecognized(unrecognized, true)
+	})
+	if !strings.Contains(output, "Unrecognized:") || !strings.Contains(output, ".lockb") || !strings.Contains(output, "123,456") {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+
+	json := formatUnrecognizedJSON(unrecognized)
+	want := `{".lockb": {"files": 3, "bytes": 123456}, "Brewfile": {"files": 1, "bytes": 42}}`
+	if json != want {
+		t.Errorf("formatUnrecognizedJSON = %s, want %s", json, want)
+	}
+
+	output = captureStdout(func() {
+		PrintUnrecognized(nil, false)
+	})
+	if output != "" {
+		t.Errorf("expected no output without unrecognized files, got %q", output)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	langStats := map[string]*LanguageStats{
+		"Go":  {Language: "Go", FileCount: 3, BlankLines: 10, CommentLines: 20, CodeLines: 70},
+		"C++": {Language: "C++", FileCount: 1, CodeLines: 5},
+	}
+	skips := make(SkipStats)
+	skips.add(SkipBinary, "logo.png")
+	skips.add(SkipBinary, "app.exe")
+	summary := &RunSummary{ProcessedFiles: 4, SkippedFiles: 2, ErrorCount: 1, Partial: true, Skipped: skips}
+
+	var buf strings.Builder
+	WritePrometheus(&buf, `C:\src\"app"`, langStats, summary, 1500*time.Millisecond, time.Unix(1700000000, 0))
+	output := buf.String()
+
+	label := `path="C:\\src\\\"app\""`
+	expected := []string{
+		"# HELP locc_code_lines Number of code lines per language.\n# TYPE locc_code_lines gauge\n" +
+			`locc_code_lines{` + label + `,language="C++"} 5` + "\n" +
+			`locc_code_lines{` + label + `,language="Go"} 70` + "\n",
+		`locc_files{` + label + `,language="Go"} 3`,
+		`locc_comment_lines{` + label + `,language="Go"} 20`,
+		`locc_blank_lines{` + label + `,language="Go"} 10`,
+		`locc_files_processed{` + label + `} 4`,
+		`locc_files_skipped{` + label + `} 2`,
+		`locc_files_skipped_by_reason{` + label + `,reason="binary"} 2`,
+		`locc_errors{` + label + `} 1`,
+		`locc_scan_partial{` + label + `} 1`,
+		`locc_scan_duration_seconds{` + label + `} 1.5`,
+		`locc_scan_timestamp_seconds{` + label + `} 1700000000`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+
+	// Every line is a comment or a sample
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		if !strings.HasPrefix(line, "# ") && !strings.HasPrefix(line, "locc_") {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectManifests defines the manifest file names that mark a project root
+var ProjectManifests = map[string]bool{
+	"go.mod":           true,
+	"package.json":     true,
+	"Cargo.toml":       true,
+	"pom.xml":          true,
+	"pyproject.toml":   true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+}
+
+// noProject is the name of the bucket for files outside any detected project
+const noProject = "(no project)"
+
+// Project represents a detected project root
+type Project struct {
+	Path      string
+	Manifests []string
+}
+
+// ProjectStats holds aggregated statistics for a project
+type ProjectStats struct {
+	Project   Project
+	Stats     LanguageStats
+	Languages map[string]*LanguageStats
+}
+
+// IsProjectManifest checks if the file name marks a project root
+func IsProjectManifest(fileName string) bool {
+	return ProjectManifests[fileName]
+}
+
+// AggregateProjectStats aggregates file statistics per project. Each file is
+// attributed to the innermost project containing it; files outside every
+// project are grouped under the "(no project)" bucket.
+func AggregateProjectStats(fileStats []*FileStats, rootPath string, projects []Project) map[string]*ProjectStats {
+	projectStats := make(map[string]*ProjectStats)
+
+	// Sort roots longest first so the first match is the innermost project
+	roots := make([]Project, len(projects))
+	copy(roots, projects)
+	sort.Slice(roots, func(i, j int) bool {
+		if len(roots[i].Path) != len(roots[j].Path) {
+			return len(roots[i].Path) > len(roots[j].Path)
+		}
+		return roots[i].Path < roots[j].Path
+	})
+
+	for _, fs := range fileStats {
+		if fs == nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(rootPath, fs.FilePath)
+		if err != nil {
+			rel = fs.FilePath
+		}
+		dir := filepath.ToSlash(filepath.Dir(rel))
+
+		project := Project{Path: noProject}
+		for _, root := range roots {
+			if root.Path == "." || dir == root.Path || strings.HasPrefix(dir, root.Path+"/") {
+				project = root
+				break
+			}
+		}
+
+		ps, exists := projectStats[project.Path]
+		if !exists {
+			ps = &ProjectStats{
+				Project:   project,
+				Stats:     LanguageStats{Language: project.Path},
+				Languages: make(map[string]*LanguageStats),
+			}
+			projectStats[project.Path] = ps
+		}
+
+		ps.Stats.add(fs)
+		addLanguageStats(ps.Languages, fs)
+	}
+
+	return projectStats
+}
+
+// sortProjectsByCode sorts projects by code lines in descending order
+func sortProjectsByCode(projectStats map[string]*ProjectStats) []string {
+	paths := make([]string, 0, len(projectStats))
+	for path := range projectStats {
+		paths = append(paths, path)
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := projectStats[paths[i]], projectStats[paths[j]]
+		if a.Stats.CodeLines != b.Stats.CodeLines {
+			return a.Stats.CodeLines > b.Stats.CodeLines
+		}
+		return paths[i] < paths[j]
+	})
+
+	return paths
+}
+
+// PrintProjects prints statistics per detected project
+func PrintProjects(projectStats map[string]*ProjectStats, formatted bool) {
+	printGroupHeader("Project")
+
+	for _, path := range sortProjectsByCode(projectStats) {
+		ps := projectStats[path]
+		name := path
+		if len(ps.Project.Manifests) > 0 {
+			name += " (" + strings.Join(ps.Project.Manifests, ", ") + ")"
+		}
+		printDirRow(name, &ps.Stats, formatted)
+		printLanguageRows(ps.Languages, "", formatted)
+	}
+
+	printGroupSeparator()
+	fmt.Println()
+}
+
+// PrintProjectsJSON prints statistics per detected project in JSON format
+func PrintProjectsJSON(projectStats map[string]*ProjectStats, total *LanguageStats) {
+	fmt.Println("{")
+	fmt.Println("  \"projects\": {")
+
+	paths := sortProjectsByCode(projectStats)
+	for i, path := range paths {
+		ps := projectStats[path]
+		comma := ","
+		if i == len(paths)-1 {
+			comma = ""
+		}
+
+		manifests := make([]string, len(ps.Project.Manifests))
+		for j, m := range ps.Project.Manifests {
+			manifests[j] = jsonString(m)
+		}
+
+		fmt.Printf("    %s: {\n", jsonString(path))
+		fmt.Printf("      \"manifests\": [%s],\n", strings.Join(manifests, ", "))
+		fmt.Printf("      \"stats\": %s,\n", formatStatsJSON(&ps.Stats))
+		printLanguagesJSON(ps.Languages, "      ")
+		fmt.Printf("    }%s\n", comma)
+	}
+
+	fmt.Println("  },")
+	fmt.Printf("  \"total\": %s\n", formatStatsJSON(total))
+	fmt.Println("}")
+}
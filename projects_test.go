@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggregateProjectStats(t *testing.T) {
+	root := "repo"
+	projects := []Project{
+		{Path: ".", Manifests: []string{"go.mod"}},
+		{Path: "services/api", Manifests: []string{"go.mod"}},
+		{Path: "services/api/web", Manifests: []string{"package.json"}},
+	}
+	fileStats := []*FileStats{
+		{FilePath: filepath.Join(root, "main.go"), Language: "Go", CodeLines: 10},
+		{FilePath: filepath.Join(root, "services", "api", "handler.go"), Language: "Go", CodeLines: 20},
+		{FilePath: filepath.Join(root, "services", "api", "web", "src", "app.ts"), Language: "TypeScript", CodeLines: 30},
+		{FilePath: filepath.Join(root, "services", "apiclient", "client.go"), Language: "Go", CodeLines: 5},
+	}
+
+	projectStats := AggregateProjectStats(fileStats, root, projects)
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{".", 15},
+		{"services/api", 20},
+		{"services/api/web", 30},
+	}
+	for _, tt := range tests {
+		ps := projectStats[tt.path]
+		if ps == nil {
+			t.Errorf("project %q not found", tt.path)
+			continue
+		}
+		if ps.Stats.CodeLines != tt.code {
+			t.Errorf("project %q code = %d, want %d", tt.path, ps.Stats.CodeLines, tt.code)
+		}
+	}
+
+	if projectStats["services/api/web"].Languages["TypeScript"] == nil {
+		t.Error("services/api/web should have a TypeScript breakdown")
+	}
+}
+
+func TestAggregateProjectStatsNoProject(t *testing.T) {
+	projectStats := AggregateProjectStats([]*FileStats{
+		{FilePath: filepath.Join("repo", "script.py"), Language: "Python", CodeLines: 3},
+	}, "repo", nil)
+
+	if ps := projectStats[noProject]; ps == nil || ps.Stats.CodeLines != 3 {
+		t.Errorf("%s bucket = %+v, want 3 code lines", noProject, ps)
+	}
+}
+
+func TestWalkerDetectsProjects(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := []string{
+		"go.mod",
+		"cmd/main.go",
+		"web/package.json",
+		"web/node_modules/dep/package.json",
+		"lib/Cargo.toml",
+	}
+	for _, f := range files {
+		fullPath := filepath.Join(tmpDir, f)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	walker := NewWalker(tmpDir, 2)
+	walker.Walk()
+	projects := walker.GetProjects()
+
+	var paths []string
+	for _, p := range projects {
+		paths = append(paths, p.Path+":"+strings.Join(p.Manifests, ","))
+	}
+	got := strings.Join(paths, " ")
+	want := ".:go.mod lib:Cargo.toml web:package.json"
+	if got != want {
+		t.Errorf("GetProjects() = %q, want %q", got, want)
+	}
+}
+
+func TestPrintProjects(t *testing.T) {
+	projectStats := AggregateProjectStats([]*FileStats{
+		{FilePath: filepath.Join("repo", "api", "main.go"), Language: "Go", CodeLines: 7},
+	}, "repo", []Project{{Path: "api", Manifests: []string{"go.mod"}}})
+
+	output := captureStdout(func() {
+		PrintProjects(projectStats, false)
+	})
+	if !strings.Contains(output, "api (go.mod)") {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+
+	output = captureStdout(func() {
+		PrintProjectsJSON(projectStats, &LanguageStats{Language: "Total", CodeLines: 7})
+	})
+	if !strings.Contains(output, "\"projects\"") || !strings.Contains(output, "\"manifests\": [\"go.mod\"]") {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// maxRequestSize bounds the JSON body of a count request
+const maxRequestSize = 1 << 20
+
+// defaultMaxConcurrent is the default number of scans the server runs at once
+const defaultMaxConcurrent = 4
+
+// ServeConfig holds the configuration of the serve command
+type ServeConfig struct {
+	Config
+	Addr          string
+	Allow         []string
+	MaxConcurrent int
+}
+
+// CountRequest is the JSON body of a count request. Options mirror the scan
+// flags of the same names.
+type CountRequest struct {
+	Path             string   `json:"path"`
+	Include          []string `json:"include"`
+	Exclude          []string `json:"exclude"`
+	IncludeRegex     []string `json:"include_regex"`
+	ExcludeRegex     []string `json:"exclude_regex"`
+	Ignore           []string `json:"ignore"`
+	Languages        []string `json:"languages"`
+	ExcludeLanguages []string `json:"exclude_languages"`
+	Hidden           bool     `json:"hidden"`
+	Other            bool     `json:"other"`
+	Archives         bool     `json:"archives"`
+	MaxDepth         int      `json:"max_depth"`
+	MaxFileSize      string   `json:"max_file_size"`
+	MinFileSize      string   `json:"min_file_size"`
+	TestPatterns     []string `json:"test_patterns"`
+}
+
+// Server counts the directories below a list of allowed roots over HTTP
+type Server struct {
+	roots   []string
+	workers int
+	timeout time.Duration
+	// scans holds a token per running scan if their number is limited
+	scans chan struct{}
+}
+
+// requestError is an error answered with an HTTP status code
+type requestError struct {
+	status int
+	err    error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// NewServer creates a server scanning only the given roots and the
+// directories below them, with at most timeout per scan if it is positive
+func NewServer(roots []string, workers int, timeout time.Duration) (*Server, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no allowed roots (use --allow)")
+	}
+
+	s := &Server{workers: workers, timeout: timeout}
+	for _, root := range roots {
+		resolved, err := resolveRealPath(root)
+		if err != nil {
+			return nil, err
+		}
+		s.roots = append(s.roots, resolved)
+	}
+	return s, nil
+}
+
+// SetMaxConcurrent limits the number of scans running at once; count requests
+// beyond it are answered with 503 Service Unavailable. A limit of 0 or less
+// means no limit. It must be called before the server handles requests.
+func (s *Server) SetMaxConcurrent(n int) {
+	s.scans = nil
+	if n > 0 {
+		s.scans = make(chan struct{}, n)
+	}
+}
+
+// acquireScan reserves a scan slot, reporting false if all are in use
+func (s *Server) acquireScan() bool {
+	if s.scans == nil {
+		return true
+	}
+	select {
+	case s.scans <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseScan frees a slot reserved with acquireScan
+func (s *Server) releaseScan() {
+	if s.scans != nil {
+		<-s.scans
+	}
+}
+
+// errBusy answers count requests while the scan limit is reached
+var errBusy = &requestError{http.StatusServiceUnavailable, fmt.Errorf("too many scans running, retry later")}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /languages", s.handleLanguages)
+	mux.HandleFunc("POST /count", s.handleCount)
+	mux.HandleFunc("POST /count/stream", s.handleCountStream)
+	return mux
+}
+
+// handleLanguages lists the supported languages like --list-languages -f json
+func (s *Server) handleLanguages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	WriteLanguageListJSON(w, ListLanguages())
+}
+
+// handleCount answers with the JSON report of locc -f json
+func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
+	if !s.acquireScan() {
+		w.Header().Set("Retry-After", "1")
+		writeRequestError(w, errBusy)
+		return
+	}
+	defer s.releaseScan()
+
+	walker, ctx, cancel, err := s.newWalker(w, r)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	defer cancel()
+
+	fileStats, errs := walker.WalkContext(ctx)
+	langStats := AggregateStats(fileStats)
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteJSONSummary(w, langStats, TotalStats(langStats), walkSummary(walker, errs))
+}
+
+// handleCountStream answers with one NDJSON record per file as soon as it is
+// counted, followed by error records and the summary record of -f ndjson
+func (s *Server) handleCountStream(w http.ResponseWriter, r *http.Request) {
+	if !s.acquireScan() {
+		w.Header().Set("Retry-After", "1")
+		writeRequestError(w, errBusy)
+		return
+	}
+	defer s.releaseScan()
+
+	walker, ctx, cancel, err := s.newWalker(w, r)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	langStats := make(map[string]*LanguageStats)
+	errs := walker.WalkFunc(ctx, func(stats *FileStats) {
+		WriteFileNDJSON(w, stats, walker.rootPath)
+		addLanguageStats(langStats, stats)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+
+	for _, err := range errs {
+		WriteErrorNDJSON(w, err)
+	}
+	WriteSummaryNDJSON(w, langStats, TotalStats(langStats), walkSummary(walker, errs))
+}
+
+// newWalker decodes a count request into a walker for an allowed directory
+// and the context bounding its scan
+func (s *Server) newWalker(w http.ResponseWriter, r *http.Request) (*Walker, context.Context, context.CancelFunc, error) {
+	var req CountRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return nil, nil, nil, &requestError{http.StatusBadRequest, fmt.Errorf("invalid request: %v", err)}
+	}
+
+	path, err := s.allowedPath(req.Path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	config := &Config{
+		Path:            path,
+		Workers:         s.workers,
+		DirWorkers:      s.workers,
+		Includes:        req.Include,
+		Excludes:        req.Exclude,
+		IncludeRegex:    req.IncludeRegex,
+		ExcludeRegex:    req.ExcludeRegex,
+		ExcludePatterns: req.Ignore,
+		Languages:       req.Languages,
+		ExcludeLangs:    req.ExcludeLanguages,
+		IncludeHidden:   req.Hidden,
+		CountOther:      req.Other,
+		Archives:        req.Archives,
+		MaxDepth:        req.MaxDepth,
+		TestPatterns:    req.TestPatterns,
+	}
+	if req.MaxFileSize != "" {
+		if config.MaxFileSize, err = parseSize(req.MaxFileSize); err != nil {
+			return nil, nil, nil, &requestError{http.StatusBadRequest, err}
+		}
+	}
+	if req.MinFileSize != "" {
+		if config.MinFileSize, err = parseSize(req.MinFileSize); err != nil {
+			return nil, nil, nil, &requestError{http.StatusBadRequest, err}
+		}
+	}
+
+	walker, err := newWalkerFromConfig(config)
+	if err != nil {
+		return nil, nil, nil, &requestError{http.StatusBadRequest, err}
+	}
+	// Links out of the allowed roots must not expose the files they point to
+	walker.SetSymlinkFilter(s.isAllowed)
+
+	// Stop when the client goes away or the scan takes too long
+	if s.timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		return walker, ctx, cancel, nil
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	return walker, ctx, cancel, nil
+}
+
+// allowedPath resolves a requested directory, following symlinks, and checks
+// that it is one of the allowed roots or below one
+func (s *Server) allowedPath(path string) (string, error) {
+	if path == "" {
+		return "", &requestError{http.StatusBadRequest, fmt.Errorf("missing path")}
+	}
+
+	forbidden := &requestError{http.StatusForbidden, fmt.Errorf("%s is not below an allowed root", path)}
+	resolved, err := resolveRealPath(path)
+	if err != nil {
+		// Only reveal whether paths below the allowed roots exist
+		abs, absErr := filepath.Abs(path)
+		if absErr != nil || !s.isAllowed(abs) {
+			return "", forbidden
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			return "", &requestError{http.StatusNotFound, fmt.Errorf("%s does not exist", path)}
+		}
+		return "", &requestError{http.StatusBadRequest, err}
+	}
+	if !s.isAllowed(resolved) {
+		return "", forbidden
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", &requestError{http.StatusBadRequest, err}
+	}
+	if !info.IsDir() {
+		return "", &requestError{http.StatusBadRequest, fmt.Errorf("%s is not a directory", path)}
+	}
+	return resolved, nil
+}
+
+// isAllowed reports whether an absolute path is an allowed root or below one
+func (s *Server) isAllowed(path string) bool {
+	for _, root := range s.roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRealPath returns the absolute path of a file with symlinks resolved
+func resolveRealPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// walkSummary returns the run summary of a finished walk
+func walkSummary(walker *Walker, errs []error) *RunSummary {
+	return &RunSummary{
+		ProcessedFiles: walker.GetProcessedCount(),
+		SkippedFiles:   walker.GetSkippedCount(),
+		ErrorCount:     len(errs),
+		Partial:        walker.IsPartial(),
+		Skipped:        walker.GetSkipStats(),
+		Unrecognized:   walker.GetUnrecognized(),
+	}
+}
+
+// writeRequestError answers a failed request with a JSON error object
+func writeRequestError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		status = reqErr.status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "{\"error\": %s}\n", jsonString(err.Error()))
+}
+
+// RunServe executes the serve command
+func RunServe(config *ServeConfig) error {
+	if config.Verbose {
+		SetLogLevel(LogLevelDebug)
+	} else if config.Quiet {
+		SetLogLevel(LogLevelSilent)
+	}
+
+	server, err := NewServer(config.Allow, config.Workers, config.Timeout)
+	if err != nil {
+		return err
+	}
+	server.SetMaxConcurrent(config.MaxConcurrent)
+
+	httpServer := &http.Server{
+		Addr:              config.Addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Stop accepting requests on Ctrl-C and let running scans finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	if !config.Quiet {
+		fmt.Printf("Serving counts of %s on %s\n", strings.Join(server.roots, ", "), config.Addr)
+	}
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// parseServeFlags parses the arguments of the serve command
+func parseServeFlags(args []string) (*ServeConfig, error) {
+	config := &ServeConfig{}
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	registerServeFlags(fs, config)
+	fs.Usage = printServeUsage
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		printServeUsage()
+		return nil, fmt.Errorf("serve takes no arguments, got %q (use --allow to list directories)", fs.Arg(0))
+	}
+
+	if err := applyConfig(fs, &config.Config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// registerServeFlags defines the flags of the serve command
+func registerServeFlags(fs *flag.FlagSet, config *ServeConfig) {
+	fs.StringVar(&config.Addr, "addr", ":8080", "Address to listen on")
+	fs.Var((*listFlag)(&config.Allow), "allow", "Comma-separated list of directories that may be scanned, including the directories below them")
+	fs.StringVar(&config.ConfigFile, "config", "", "Config file to use instead of the .locc.toml or .locc.yaml found from the current directory upward")
+	fs.IntVar(&config.Workers, "workers", runtime.NumCPU(), "Number of worker goroutines per scan")
+	fs.IntVar(&config.Workers, "w", runtime.NumCPU(), "Number of worker goroutines per scan (shorthand)")
+	fs.DurationVar(&config.Timeout, "timeout", 0, "Stop a scan after this duration and answer with partial results (e.g., 30s)")
+	fs.IntVar(&config.MaxConcurrent, "max-concurrent", defaultMaxConcurrent, "Maximum number of scans running at once, 0 for no limit; further count requests are answered with 503")
+	fs.BoolVar(&config.Verbose, "verbose", false, "Enable verbose output")
+	fs.BoolVar(&config.Verbose, "v", false, "Enable verbose output (shorthand)")
+	fs.BoolVar(&config.Quiet, "quiet", false, "Suppress non-essential output")
+	fs.BoolVar(&config.Quiet, "q", false, "Suppress non-essential output (shorthand)")
+}
+
+func printServeUsage() {
+	fmt.Printf(`Usage:
+  %s serve [options] --allow <dirs>
+
+Serves counts of the allowed directories over HTTP as a JSON API:
+
+  GET  /languages         Supported languages, like --list-languages -f json
+  POST /count             JSON report of a directory, like -f json
+  POST /count/stream      NDJSON records streamed as files are counted, like -f ndjson
+
+Count requests take a JSON body with the directory and scan options, e.g.
+{"path": "/srv/repos/app", "exclude": ["docs"], "languages": ["Go"]}.
+
+Options:
+  --addr <addr>           Address to listen on (default: :8080)
+  --allow <dirs>          Comma-separated list of directories that may be scanned,
+                          including the directories below them
+  --config <file>         Config file to use instead of the .locc.toml or .locc.yaml
+                          found from the current directory upward
+  -w, --workers <n>       Number of worker goroutines per scan (default: number of CPUs)
+  --timeout <duration>    Stop a scan after this duration and answer with partial results
+  --max-concurrent <n>    Maximum number of scans running at once, 0 for no limit;
+                          further count requests are answered with 503 (default: 4)
+  -v, --verbose           Enable verbose output
+  -q, --quiet             Suppress non-essential output
+
+Examples:
+  %s serve --addr localhost:8080 --allow /srv/repos
+  curl -d '{"path": "/srv/repos/app"}' localhost:8080/count
+
+`, AppName, AppName)
+}
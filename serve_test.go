@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "repo", "docs"), 0755)
+	os.WriteFile(filepath.Join(root, "repo", "main.go"), []byte("package main\n\n// main\nfunc main() {}\n"), 0644)
+	os.WriteFile(filepath.Join(root, "repo", "util.py"), []byte("x = 1\n"), 0644)
+	os.WriteFile(filepath.Join(root, "repo", "docs", "guide.md"), []byte("# Guide\n"), 0644)
+
+	server, err := NewServer([]string{filepath.Join(root, "repo")}, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return ts, root
+}
+
+func TestNewServerNoRoots(t *testing.T) {
+	if _, err := NewServer(nil, 2, 0); err == nil {
+		t.Error("expected an error without allowed roots")
+	}
+}
+
+func TestServeCount(t *testing.T) {
+	ts, root := newTestServer(t)
+	body := `{"path": ` + jsonString(filepath.Join(root, "repo")) + `, "exclude": ["docs"]}`
+
+	resp, err := http.Post(ts.URL+"/count", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	var report struct {
+		Languages map[string]struct {
+			Files int `json:"files"`
+			Code  int `json:"code"`
+		} `json:"languages"`
+		Total struct {
+			Files int `json:"files"`
+		} `json:"total"`
+		Summary struct {
+			Processed int `json:"files_processed"`
+		} `json:"summary"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Total.Files != 2 || report.Languages["Go"].Code != 2 || report.Summary.Processed != 2 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if _, ok := report.Languages["Markdown"]; ok {
+		t.Error("excluded docs directory was counted")
+	}
+}
+
+func TestServeCountStream(t *testing.T) {
+	ts, root := newTestServer(t)
+	body := `{"path": ` + jsonString(filepath.Join(root, "repo")) + `, "languages": ["Go", "Python"]}`
+
+	resp, err := http.Post(ts.URL+"/count/stream", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var record struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", scanner.Text(), err)
+		}
+		types = append(types, record.Type)
+	}
+	if strings.Join(types, ",") != "file,file,summary" {
+		t.Errorf("record types = %v, want two files and a summary", types)
+	}
+}
+
+func TestServeLanguages(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/languages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var langs []struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&langs); err != nil {
+		t.Fatal(err)
+	}
+	if len(langs) != len(ListLanguages()) {
+		t.Errorf("got %d languages, want %d", len(langs), len(ListLanguages()))
+	}
+}
+
+func TestServeRequestErrors(t *testing.T) {
+	ts, root := newTestServer(t)
+	os.Symlink(filepath.Join(root), filepath.Join(root, "repo", "escape"))
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"outside root", `{"path": ` + jsonString(root) + `}`, http.StatusForbidden},
+		{"dot dot", `{"path": ` + jsonString(filepath.Join(root, "repo", "..")) + `}`, http.StatusForbidden},
+		{"symlink out of root", `{"path": ` + jsonString(filepath.Join(root, "repo", "escape")) + `}`, http.StatusForbidden},
+		{"missing outside root", `{"path": "/no/such/dir"}`, http.StatusForbidden},
+		{"missing below root", `{"path": ` + jsonString(filepath.Join(root, "repo", "nope")) + `}`, http.StatusNotFound},
+		{"file", `{"path": ` + jsonString(filepath.Join(root, "repo", "main.go")) + `}`, http.StatusBadRequest},
+		{"no path", `{}`, http.StatusBadRequest},
+		{"unknown field", `{"pth": "x"}`, http.StatusBadRequest},
+		{"unknown language", `{"path": ` + jsonString(filepath.Join(root, "repo")) + `, "languages": ["Nope"]}`, http.StatusBadRequest},
+		{"bad size", `{"path": ` + jsonString(filepath.Join(root, "repo")) + `, "max_file_size": "big"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+"/count", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+				t.Errorf("expected a JSON error, got %+v (%v)", body, err)
+			}
+		})
+	}
+
+	resp, err := http.Get(ts.URL + "/count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /count status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestParseServeFlagsEnvironment(t *testing.T) {
+	t.Setenv("LOCC_ALLOW", "/")
+	t.Setenv("LOCC_ADDR", ":1")
+
+	config, err := parseServeFlags([]string{"--allow", "repo"})
+	if err != nil {
+		t.Fatalf("parseServeFlags error: %v", err)
+	}
+	if !reflect.DeepEqual(config.Allow, []string{"repo"}) || config.Addr != ":8080" {
+		t.Errorf("allow = %q, addr = %q; want them set only on the command line", config.Allow, config.Addr)
+	}
+}
+
+func TestServeMaxConcurrent(t *testing.T) {
+	root := t.TempDir()
+	server, err := NewServer([]string{root}, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetMaxConcurrent(1)
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	body := `{"path": ` + jsonString(root) + `}`
+
+	// Hold the only slot as a running scan would
+	server.acquireScan()
+	for _, endpoint := range []string{"/count", "/count/stream"} {
+		resp, err := http.Post(ts.URL+endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+			t.Errorf("%s: status = %d, want 503 with Retry-After while the limit is reached", endpoint, resp.StatusCode)
+		}
+	}
+
+	server.releaseScan()
+	resp, err := http.Post(ts.URL+"/count", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200 once the slot is free", resp.StatusCode)
+	}
+}
+
+func TestServeSymlinks(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	os.MkdirAll(repo, 0755)
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(root, "secret.go"), []byte("package secret\n"), 0644)
+	if err := os.Symlink(filepath.Join(root, "secret.go"), filepath.Join(repo, "secret.go")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	os.Symlink("main.go", filepath.Join(repo, "alias.go"))
+
+	server, err := NewServer([]string{repo}, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/count/stream", "application/json", strings.NewReader(`{"path": `+jsonString(repo)+`}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var record struct {
+			Type string `json:"type"`
+			Path string `json:"path"`
+		}
+		json.Unmarshal(scanner.Bytes(), &record)
+		if record.Type == "file" {
+			files = append(files, record.Path)
+		}
+		if strings.Contains(scanner.Text(), "secret") {
+			t.Errorf("link out of the allowed root was read: %s", scanner.Text())
+		}
+	}
+	sort.Strings(files)
+	if !reflect.DeepEqual(files, []string{"alias.go", "main.go"}) {
+		t.Errorf("counted %q, want the link inside the root and its target", files)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot file format
+const SnapshotVersion = 1
+
+// Snapshot holds the statistics of a scan saved for a later comparison
+type Snapshot struct {
+	Root      string
+	Created   time.Time
+	Languages map[string]*LanguageStats
+	Total     *LanguageStats
+	// Files holds per-file statistics with slash separated paths relative to Root,
+	// with the hash of their content
+	Files []*FileStats
+}
+
+// SnapshotConfig holds the configuration of the snapshot command
+type SnapshotConfig struct {
+	Config
+	Output string
+}
+
+// NewSnapshot creates a snapshot of the files counted below rootPath
+func NewSnapshot(rootPath string, fileStats []*FileStats) *Snapshot {
+	files := make([]*FileStats, 0, len(fileStats))
+	for _, fs := range fileStats {
+		rel, err := filepath.Rel(rootPath, fs.FilePath)
+		if err != nil {
+			rel = fs.FilePath
+		}
+		file := *fs
+		file.FilePath = filepath.ToSlash(rel)
+		files = append(files, &file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].FilePath < files[j].FilePath
+	})
+
+	langStats := AggregateStats(files)
+	return &Snapshot{
+		Root:      rootPath,
+		Created:   time.Now().UTC(),
+		Languages: langStats,
+		Total:     TotalStats(langStats),
+		Files:     files,
+	}
+}
+
+// TakeSnapshot scans the configured directory into a snapshot
+func TakeSnapshot(config *Config) (*Snapshot, []error, error) {
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.IsDir() {
+		return nil, nil, fmt.Errorf("%s is not a directory", config.Path)
+	}
+
+	walker, err := newWalkerFromConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	walker.SetHashContent(true)
+	fileStats, errors := walker.Walk()
+	return NewSnapshot(config.Path, fileStats), errors, nil
+}
+
+// WriteSnapshot writes a snapshot as JSON, with one line per file
+func WriteSnapshot(w io.Writer, s *Snapshot) error {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "{")
+	fmt.Fprintf(&b, "  \"version\": %d,\n", SnapshotVersion)
+	fmt.Fprintf(&b, "  \"root\": %s,\n", jsonString(s.Root))
+	fmt.Fprintf(&b, "  \"created\": %s,\n", jsonString(s.Created.Format(time.RFC3339)))
+	fmt.Fprintln(&b, "  \"languages\": {")
+	langs := sortLanguagesByCode(s.Languages)
+	for i, lang := range langs {
+		fmt.Fprintf(&b, "    %s: %s%s\n", jsonString(lang), formatStatsJSON(s.Languages[lang]), jsonComma(i, len(langs)))
+	}
+	fmt.Fprintln(&b, "  },")
+	fmt.Fprintf(&b, "  \"total\": %s,\n", formatStatsJSON(s.Total))
+	fmt.Fprintln(&b, "  \"files\": [")
+	for i, fs := range s.Files {
+		hash := ""
+		if fs.Hash != "" {
+			hash = fmt.Sprintf(", \"sha256\": %s", jsonString(fs.Hash))
+		}
+		fmt.Fprintf(&b, "    {\"path\": %s, \"language\": %s, \"blank\": %d, \"comment\": %d, \"code\": %d, \"total\": %d%s}%s\n",
+			jsonString(fs.FilePath), jsonString(fs.Language), fs.BlankLines, fs.CommentLines, fs.CodeLines, fs.TotalLines, hash, jsonComma(i, len(s.Files)))
+	}
+	fmt.Fprintln(&b, "  ]")
+	fmt.Fprintln(&b, "}")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// jsonComma returns the separator following element i of n
+func jsonComma(i, n int) string {
+	if i == n-1 {
+		return ""
+	}
+	return ","
+}
+
+// LoadSnapshot reads a snapshot written by the snapshot command
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Version int    `json:"version"`
+		Root    string `json:"root"`
+		Created string `json:"created"`
+		Files   []struct {
+			Path    string `json:"path"`
+			Lang    string `json:"language"`
+			Blank   int    `json:"blank"`
+			Comment int    `json:"comment"`
+			Code    int    `json:"code"`
+			Total   int    `json:"total"`
+			Hash    string `json:"sha256"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %v", path, err)
+	}
+	if file.Version == 0 {
+		return nil, fmt.Errorf("%s is not a snapshot (create one with \"%s snapshot -o %s\")", path, AppName, filepath.Base(path))
+	}
+	if file.Version > SnapshotVersion {
+		return nil, fmt.Errorf("%s has snapshot version %d, this version of %s reads up to %d", path, file.Version, AppName, SnapshotVersion)
+	}
+
+	// Language totals are derived from the files so they always agree
+	files := make([]*FileStats, 0, len(file.Files))
+	for _, f := range file.Files {
+		files = append(files, &FileStats{
+			FilePath:     f.Path,
+			Language:     f.Lang,
+			BlankLines:   f.Blank,
+			CommentLines: f.Comment,
+			CodeLines:    f.Code,
+			TotalLines:   f.Total,
+			Hash:         f.Hash,
+		})
+	}
+	langStats := AggregateStats(files)
+	s := &Snapshot{
+		Root:      file.Root,
+		Languages: langStats,
+		Total:     TotalStats(langStats),
+		Files:     files,
+	}
+	if created, err := time.Parse(time.RFC3339, file.Created); err == nil {
+		s.Created = created
+	}
+	return s, nil
+}
+
+// RunSnapshot executes the snapshot command
+func RunSnapshot(config *SnapshotConfig) error {
+	if config.Verbose {
+		SetLogLevel(LogLevelDebug)
+	} else if config.Quiet {
+		SetLogLevel(LogLevelSilent)
+	}
+	if config.Path == "" {
+		config.Path = "."
+	}
+
+	snapshot, errors, err := TakeSnapshot(&config.Config)
+	if err != nil {
+		return err
+	}
+	// Errors go to standard error, which keeps standard output valid JSON
+	// when the snapshot is written there
+	if config.ShowErrors {
+		defer WriteErrors(os.Stderr, errors)
+	}
+	if config.Output == "" || config.Output == "-" {
+		return WriteSnapshot(os.Stdout, snapshot)
+	}
+
+	file, err := os.Create(config.Output)
+	if err != nil {
+		return err
+	}
+	if err := WriteSnapshot(file, snapshot); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if !config.Quiet {
+		fmt.Printf("Saved %d files (%d code lines) to %s\n", len(snapshot.Files), snapshot.Total.CodeLines, config.Output)
+	}
+	return nil
+}
+
+// parseSnapshotFlags parses the arguments of the snapshot command
+func parseSnapshotFlags(args []string) (*SnapshotConfig, error) {
+	config := &SnapshotConfig{}
+
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	registerScanFlags(fs, &config.Config)
+	registerSnapshotFlags(fs, config)
+	fs.Usage = printSnapshotUsage
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		config.Path = fs.Arg(0)
+	}
+
+	if err := applyConfig(fs, &config.Config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// registerSnapshotFlags defines the flags specific to the snapshot command
+func registerSnapshotFlags(fs *flag.FlagSet, config *SnapshotConfig) {
+	fs.StringVar(&config.Output, "output", "", "File to write the snapshot to (default: standard output)")
+	fs.StringVar(&config.Output, "o", "", "File to write the snapshot to (shorthand)")
+}
+
+func printSnapshotUsage() {
+	fmt.Printf(`Usage:
+  %s snapshot [options] [path]
+
+Saves the statistics of every counted file to a JSON snapshot, to compare a later
+state of the code against with "%s compare".
+
+Options:
+  -o, --output <file>     File to write the snapshot to (default: standard output)
+  --config <file>         Config file to use instead of the .locc.toml or .locc.yaml
+                          found from the path upward
+  -w, --workers <n>       Number of worker goroutines (default: number of CPUs)
+  -H, --hidden            Include hidden files and directories
+  --include <globs>       Comma-separated list of globs relative to the root to count
+  -x, --exclude <globs>   Comma-separated list of directory names or globs relative to the root to exclude
+  -i, --ignore <patterns> Comma-separated list of patterns to exclude files
+  --lang <languages>      Comma-separated list of languages to count (e.g., "Go,TypeScript")
+  --exclude-lang <langs>  Comma-separated list of languages to exclude (e.g., "Markdown,JSON")
+  --other                 Count files without a registered language as plain text under "Other"
+  -e, --errors            Show detailed error messages
+  -q, --quiet             Suppress non-essential output
+
+All scan options of the main command are accepted.
+
+Examples:
+  %s snapshot -o baseline.json .     Save the current state of the code
+
+`, AppName, AppName, AppName)
+}
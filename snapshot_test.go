@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main\n\n// entry\nfunc main() {}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "run.py"), []byte("print(1)\n"), 0644)
+
+	snapshot, errs, err := TakeSnapshot(&Config{Path: tmpDir, Workers: 2})
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("TakeSnapshot error: %v %v", err, errs)
+	}
+
+	var paths []string
+	for _, fs := range snapshot.Files {
+		paths = append(paths, fs.FilePath)
+	}
+	if !reflect.DeepEqual(paths, []string{"run.py", "src/main.go"}) {
+		t.Errorf("snapshot paths = %v, want sorted relative paths", paths)
+	}
+
+	if h := snapshot.Files[0].Hash; h != fmt.Sprintf("%x", sha256.Sum256([]byte("print(1)\n"))) {
+		t.Errorf("run.py hash = %q", h)
+	}
+
+	file := filepath.Join(tmpDir, "snapshot.json")
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, snapshot); err != nil {
+		t.Fatalf("WriteSnapshot error: %v", err)
+	}
+	os.WriteFile(file, buf.Bytes(), 0644)
+
+	loaded, err := LoadSnapshot(file)
+	if err != nil {
+		t.Fatalf("LoadSnapshot error: %v", err)
+	}
+	for i, fs := range snapshot.Files {
+		// Only line counts and content hashes are saved
+		want := *fs
+		want.Extension, want.IsTest = "", false
+		if i >= len(loaded.Files) || *loaded.Files[i] != want {
+			t.Errorf("loaded file %d differs, want %+v", i, want)
+		}
+	}
+	if !reflect.DeepEqual(loaded.Languages, snapshot.Languages) || *loaded.Total != *snapshot.Total {
+		t.Errorf("loaded totals differ: %+v, want %+v", loaded.Total, snapshot.Total)
+	}
+	if !loaded.Created.Equal(snapshot.Created.Truncate(1e9)) {
+		t.Errorf("Created = %v, want %v", loaded.Created, snapshot.Created)
+	}
+
+	// Snapshots can be used as a --max-growth baseline
+	if code, err := LoadBaseline(file); err != nil || code != snapshot.Total.CodeLines {
+		t.Errorf("LoadBaseline = %d, %v, want %d", code, err, snapshot.Total.CodeLines)
+	}
+}
+
+func TestLoadSnapshotErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`{"languages": {}, "total": {"code": 1}}`, "is not a snapshot"},
+		{`{"version": 99, "files": []}`, "has snapshot version 99"},
+		{`{"version": 1, "files": [`, "invalid snapshot"},
+	}
+
+	for i, tt := range tests {
+		path := filepath.Join(tmpDir, "snapshot.json")
+		os.WriteFile(path, []byte(tt.content), 0644)
+		if _, err := LoadSnapshot(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("case %d: LoadSnapshot error = %v, want %q", i, err, tt.want)
+		}
+	}
+}
+
+func TestRunSnapshotErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644)
+	if err := os.Symlink(filepath.Join(tmpDir, "missing"), filepath.Join(tmpDir, "broken.go")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// Errors are reported on standard error whether the snapshot goes to a
+	// file or to standard output
+	for _, output := range []string{"", filepath.Join(tmpDir, "snapshot.json")} {
+		config, err := parseSnapshotFlags([]string{"-e", "-q", "-o", output, tmpDir})
+		if err != nil {
+			t.Fatalf("parseSnapshotFlags error: %v", err)
+		}
+		var stdout string
+		stderr := captureStderr(func() {
+			stdout = captureStdout(func() {
+				err = RunSnapshot(config)
+			})
+		})
+		if err != nil {
+			t.Fatalf("RunSnapshot error: %v", err)
+		}
+		if !strings.Contains(stderr, "broken.go") {
+			t.Errorf("output %q: stderr = %q, want the error for broken.go", output, stderr)
+		}
+		if output == "" && !json.Valid([]byte(stdout)) {
+			t.Errorf("stdout is not a valid snapshot:\n%s", stdout)
+		}
+	}
+}
@@ -4,19 +4,60 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"strings"
+	"testing"
 )
 
+// TestMain isolates the tests from the host's LOCC_* variables and user config
+// file, and removes the tree generated for the walker benchmarks after the run
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "locc-home")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_CONFIG_HOME", home)
+	os.Setenv("APPDATA", home)
+	for _, env := range os.Environ() {
+		if name, _, _ := strings.Cut(env, "="); strings.HasPrefix(name, configEnvPrefix) {
+			os.Unsetenv(name)
+		}
+	}
+
+	code := m.Run()
+	if benchTreeDir != "" {
+		os.RemoveAll(benchTreeDir)
+	}
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
 func captureStdout(f func()) string {
-	old := os.Stdout
+	return captureFile(&os.Stdout, f)
+}
+
+func captureStderr(f func()) string {
+	return captureFile(&os.Stderr, f)
+}
+
+// captureFile returns what f writes to the given standard stream
+func captureFile(file **os.File, f func()) string {
+	old := *file
 	r, w, _ := os.Pipe()
-	os.Stdout = w
+	*file = w
+
+	// Drain the pipe while f runs so large outputs do not fill its buffer
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
 
 	f()
 
 	w.Close()
-	os.Stdout = old
+	*file = old
 
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	return buf.String()
+	return <-done
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// TestFilePatterns defines per-language file name patterns that mark a file as test code
+var TestFilePatterns = map[string][]string{
+	"Go":             {"*_test.go"},
+	"Python":         {"test_*.py", "*_test.py"},
+	"JavaScript":     {"*.test.js", "*.spec.js"},
+	"JavaScript JSX": {"*.test.jsx", "*.spec.jsx"},
+	"TypeScript":     {"*.test.ts", "*.spec.ts"},
+	"TypeScript JSX": {"*.test.tsx", "*.spec.tsx"},
+	"Java":           {"*Test.java", "*Tests.java", "*IT.java"},
+	"Kotlin":         {"*Test.kt", "*Tests.kt"},
+	"Scala":          {"*Test.scala", "*Spec.scala", "*Suite.scala"},
+	"C#":             {"*Test.cs", "*Tests.cs"},
+	"Ruby":           {"*_spec.rb", "*_test.rb", "test_*.rb"},
+	"PHP":            {"*Test.php"},
+	"Swift":          {"*Tests.swift", "*Test.swift"},
+	"Elixir":         {"*_test.exs"},
+	"C":              {"test_*.c", "*_test.c"},
+	"C++":            {"test_*.cpp", "*_test.cpp", "*_test.cc"},
+}
+
+// TestDirectories defines directory names whose contents are always test code
+var TestDirectories = map[string]bool{
+	"__tests__": true,
+	"__mocks__": true,
+	"testdata":  true,
+}
+
+// TestDirectoryPaths defines per-language directory paths (relative, slash separated)
+// whose contents are test code
+var TestDirectoryPaths = map[string][]string{
+	"Java":   {"src/test"},
+	"Kotlin": {"src/test"},
+	"Scala":  {"src/test"},
+	"Python": {"tests", "test"},
+	"Rust":   {"tests"},
+	"Ruby":   {"spec", "test"},
+	"PHP":    {"tests"},
+	"Elixir": {"test"},
+}
+
+// IsTestFile reports whether a file is test code according to the language's
+// conventions or any of the additional glob patterns.
+// relPath is the file path relative to the scan root.
+func IsTestFile(relPath string, langName string, extraPatterns []string) bool {
+	relPath = filepath.ToSlash(relPath)
+	fileName := filepath.Base(relPath)
+
+	// Check user supplied patterns against both the name and the relative path
+	for _, pattern := range extraPatterns {
+		if matchTestPattern(pattern, relPath, fileName) {
+			return true
+		}
+	}
+
+	// Check language specific file name conventions
+	for _, pattern := range TestFilePatterns[langName] {
+		if match, err := filepath.Match(pattern, fileName); err == nil && match {
+			return true
+		}
+	}
+
+	// Check directory conventions
+	dirs := strings.Split(relPath, "/")
+	dirs = dirs[:len(dirs)-1]
+	for _, dir := range dirs {
+		if TestDirectories[dir] {
+			return true
+		}
+	}
+
+	dirPath := "/" + strings.Join(dirs, "/") + "/"
+	for _, testDir := range TestDirectoryPaths[langName] {
+		if strings.Contains(dirPath, "/"+testDir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchTestPattern matches a glob pattern against a file name, or against the
+// relative path when the pattern contains a path separator
+func matchTestPattern(pattern, relPath, fileName string) bool {
+	pattern = filepath.ToSlash(pattern)
+	if strings.Contains(pattern, "/") {
+		// A trailing "/**" marks everything below a directory
+		if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+			dirs := strings.Split(relPath, "/")
+			for i := 1; i < len(dirs); i++ {
+				match, err := filepath.Match(prefix, strings.Join(dirs[:i], "/"))
+				if err == nil && match {
+					return true
+				}
+			}
+			return false
+		}
+		match, err := filepath.Match(pattern, relPath)
+		return err == nil && match
+	}
+	match, err := filepath.Match(pattern, fileName)
+	return err == nil && match
+}
+
+// TestSplitStats holds the production and test statistics for a language
+type TestSplitStats struct {
+	Language   string
+	Production LanguageStats
+	Test       LanguageStats
+}
+
+// TestRatio returns the ratio of test code lines to production code lines.
+// It returns 0 when there is no production code.
+func (s *TestSplitStats) TestRatio() float64 {
+	if s.Production.CodeLines == 0 {
+		return 0
+	}
+	return float64(s.Test.CodeLines) / float64(s.Production.CodeLines)
+}
+
+// AggregateTestStats aggregates file statistics by language, split into
+// production and test code
+func AggregateTestStats(fileStats []*FileStats) map[string]*TestSplitStats {
+	splitStats := make(map[string]*TestSplitStats)
+
+	for _, fs := range fileStats {
+		if fs == nil {
+			continue
+		}
+
+		lang := fs.Language
+		if _, exists := splitStats[lang]; !exists {
+			splitStats[lang] = &TestSplitStats{
+				Language:   lang,
+				Production: LanguageStats{Language: lang},
+				Test:       LanguageStats{Language: lang},
+			}
+		}
+
+		target := &splitStats[lang].Production
+		if fs.IsTest {
+			target = &splitStats[lang].Test
+		}
+		target.add(fs)
+	}
+
+	return splitStats
+}
+
+// TotalTestStats calculates the production and test totals across all languages
+func TotalTestStats(splitStats map[string]*TestSplitStats) *TestSplitStats {
+	total := &TestSplitStats{
+		Language:   "Total",
+		Production: LanguageStats{Language: "Total"},
+		Test:       LanguageStats{Language: "Total"},
+	}
+
+	for _, s := range splitStats {
+		total.Production.merge(&s.Production)
+		total.Test.merge(&s.Test)
+	}
+
+	return total
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsTestFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		lang     string
+		patterns []string
+		want     bool
+	}{
+		{"main.go", "Go", nil, false},
+		{"main_test.go", "Go", nil, true},
+		{"pkg/util_test.go", "Go", nil, true},
+		{"test_app.py", "Python", nil, true},
+		{"app.py", "Python", nil, false},
+		{"tests/helpers.py", "Python", nil, true},
+		{"src/app.spec.ts", "TypeScript", nil, true},
+		{"src/app.ts", "TypeScript", nil, false},
+		{"src/__tests__/app.js", "JavaScript", nil, true},
+		{"src/test/java/com/example/Helper.java", "Java", nil, true},
+		{"src/main/java/com/example/Helper.java", "Java", nil, false},
+		{"src/test/helper.go", "Go", nil, false},
+		{"main.go", "Go", []string{"main.*"}, true},
+		{"e2e/flows/login.go", "Go", []string{"e2e/**"}, true},
+		{"app/e2e.go", "Go", []string{"e2e/**"}, false},
+		{"app/fixtures/data.go", "Go", []string{"app/fixtures/*.go"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsTestFile(tt.path, tt.lang, tt.patterns); got != tt.want {
+				t.Errorf("IsTestFile(%q, %q, %v) = %v, want %v", tt.path, tt.lang, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateTestStats(t *testing.T) {
+	fileStats := []*FileStats{
+		{Language: "Go", CodeLines: 100, TotalLines: 120},
+		{Language: "Go", CodeLines: 50, TotalLines: 60, IsTest: true},
+		{Language: "Python", CodeLines: 10, TotalLines: 10},
+	}
+
+	splitStats := AggregateTestStats(fileStats)
+
+	goStats := splitStats["Go"]
+	if goStats.Production.FileCount != 1 || goStats.Production.CodeLines != 100 {
+		t.Errorf("Go production = %+v, want 1 file and 100 code lines", goStats.Production)
+	}
+	if goStats.Test.FileCount != 1 || goStats.Test.CodeLines != 50 {
+		t.Errorf("Go test = %+v, want 1 file and 50 code lines", goStats.Test)
+	}
+	if goStats.TestRatio() != 0.5 {
+		t.Errorf("Go TestRatio() = %v, want 0.5", goStats.TestRatio())
+	}
+
+	total := TotalTestStats(splitStats)
+	if total.Production.CodeLines != 110 || total.Test.CodeLines != 50 {
+		t.Errorf("Total = %+v, want 110 production and 50 test code lines", total)
+	}
+}
+
+func TestWalkerClassifiesTests(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := []string{"main.go", "main_test.go", "e2e/flow.go"}
+	for _, f := range files {
+		fullPath := filepath.Join(tmpDir, f)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte("package main\n"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	walker := NewWalker(tmpDir, 2)
+	walker.AddTestPattern("e2e/**")
+	stats, _ := walker.Walk()
+
+	for _, s := range stats {
+		want := !strings.HasSuffix(s.FilePath, "main.go")
+		if s.IsTest != want {
+			t.Errorf("%s: IsTest = %v, want %v", s.FilePath, s.IsTest, want)
+		}
+	}
+}
+
+func TestPrintTestSplit(t *testing.T) {
+	splitStats := AggregateTestStats([]*FileStats{
+		{Language: "Go", CodeLines: 100},
+		{Language: "Go", CodeLines: 25, IsTest: true},
+	})
+	total := TotalTestStats(splitStats)
+
+	output := captureStdout(func() {
+		PrintTestSplit(splitStats, total)
+	})
+	if !strings.Contains(output, "Test Ratio") || !strings.Contains(output, "0.25") {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+
+	output = captureStdout(func() {
+		PrintTestSplitJSON(splitStats, total)
+	})
+	if !strings.Contains(output, "\"production\"") || !strings.Contains(output, "\"test_ratio\": 0.2500") {
+		t.Errorf("Output missing expected content: %s", output)
+	}
+}
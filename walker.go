@@ -1,32 +1,214 @@
 package main
 
 import (
-	"os"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
+// maxSymlinkDepth bounds the directory depth on platforms without file
+// identities, where symlink loops cannot be detected otherwise
+const maxSymlinkDepth = 255
+
+// ErrReadTimeout is reported for files that could not be read within the file timeout
+var ErrReadTimeout = errors.New("read timed out")
+
+// SkipReason describes why a file was not counted
+type SkipReason string
+
+// Reasons for skipping files
+const (
+	SkipIgnorePattern SkipReason = "ignore pattern"
+	SkipPathFilter    SkipReason = "path filter"
+	SkipBinary        SkipReason = "binary"
+	SkipHidden        SkipReason = "hidden"
+	SkipUnsupported   SkipReason = "unsupported"
+	SkipLanguage      SkipReason = "language filter"
+	SkipTooLarge      SkipReason = "too large"
+	SkipTooSmall      SkipReason = "too small"
+
+	// Files in excluded directories are never read, so this reason is only
+	// reported by Explain and for files listed with SetFiles
+	SkipExcludedDir SkipReason = "excluded directory"
+
+	// Directories below the depth limit are listed but their files not read
+	SkipDepth SkipReason = "max depth"
+
+	// Paths listed with SetFiles that do not exist, such as files deleted
+	// since the listed revision
+	SkipMissing SkipReason = "missing"
+
+	// Symlinks whose targets are rejected by the filter set with SetSymlinkFilter
+	SkipSymlinkTarget SkipReason = "symlink target"
+)
+
+// SkipStats counts skipped files per reason and extension
+type SkipStats map[SkipReason]map[string]int
+
+// noExtension is the extension key for skipped files without an extension
+const noExtension = "(no extension)"
+
+// add records a skipped file
+func (s SkipStats) add(reason SkipReason, path string) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		ext = noExtension
+	}
+	if s[reason] == nil {
+		s[reason] = make(map[string]int)
+	}
+	s[reason][ext]++
+}
+
+// Count returns the number of files skipped for a reason
+func (s SkipStats) Count(reason SkipReason) int {
+	count := 0
+	for _, n := range s[reason] {
+		count += n
+	}
+	return count
+}
+
+// Reasons returns the skip reasons sorted by file count (descending), then name
+func (s SkipStats) Reasons() []SkipReason {
+	reasons := make([]SkipReason, 0, len(s))
+	for reason := range s {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		a, b := s.Count(reasons[i]), s.Count(reasons[j])
+		if a != b {
+			return a > b
+		}
+		return reasons[i] < reasons[j]
+	})
+	return reasons
+}
+
+// Extensions returns the extensions skipped for a reason sorted by file count
+// (descending), then name
+func (s SkipStats) Extensions(reason SkipReason) []string {
+	exts := make([]string, 0, len(s[reason]))
+	for ext := range s[reason] {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		a, b := s[reason][exts[i]], s[reason][exts[j]]
+		if a != b {
+			return a > b
+		}
+		return exts[i] < exts[j]
+	})
+	return exts
+}
+
+// otherLanguage is the language files without a registered language are
+// counted as when counting them is enabled
+var otherLanguage = &Language{Name: "Other"}
+
+// resolveLanguageName returns the language of a name, matched
+// case-insensitively against the registry and the "Other" language of
+// --other, or nil if there is none
+func resolveLanguageName(name string) *Language {
+	if strings.EqualFold(name, otherLanguage.Name) {
+		return otherLanguage
+	}
+	return GetLanguageByName(name)
+}
+
+// binarySniffSize is how much of an unrecognized file is read to detect binary content
+const binarySniffSize = 8000
+
+// UnrecognizedStats counts files with an extension or name that has no registered language
+type UnrecognizedStats struct {
+	Name  string
+	Files int
+	Bytes int64
+}
+
+// Explanation describes why a file is counted as a language or skipped
+type Explanation struct {
+	Path     string
+	Language *Language
+	Reason   SkipReason
+	Detail   string
+	IsTest   bool
+
+	// Unrecognized is set if no language is registered for the file, whether
+	// it is skipped or counted as Other
+	Unrecognized bool
+}
+
+// Counted reports whether the file is counted
+func (e *Explanation) Counted() bool {
+	return e.Reason == ""
+}
+
 // FileJob represents a file to be processed
 type FileJob struct {
 	Path      string
 	Extension string
 	Language  *Language
+	// Archive marks an archive whose entries are counted instead of the file
+	Archive bool
+	// Hash requests the SHA-256 of the content in FileStats.Hash
+	Hash bool
 }
 
 // Walker handles concurrent directory traversal and file processing
 type Walker struct {
+	fsys            fs.FS
 	rootPath        string
 	numWorkers      int
+	dirWorkers      int
+	dirSem          chan struct{}
+	dirWg           sync.WaitGroup
+	ctx             context.Context
+	fileTimeout     time.Duration
+	partial         bool
 	excludeDirs     map[string]bool
 	excludePatterns []string
+	filter          *PathFilter
+	langFilter      *LanguageFilter
+	langOverrides   map[string]*Language
+	testPatterns    []string
 	includeHidden   bool
 	results         []*FileStats
+	onFile          func(stats *FileStats)
 	errors          []error
+	projects        map[string][]string
+	followSymlinks  bool
+	symlinkFilter   func(target string) bool
+	oneFileSystem   bool
+	rootDev         uint64
+	visitedDirs     map[fileID]bool
+	visitedFiles    map[fileID]bool
 	mu              sync.Mutex
 	processedFiles  int
 	skippedFiles    int
+	skipped         SkipStats
+	maxFileSize     int64
+	minFileSize     int64
+	maxDepth        int
+	countOther      bool
+	unrecognized    map[string]*UnrecognizedStats
+	files           []string
+	useFiles        bool
+	archives        bool
+	hashContent     bool
 }
 
 // NewWalker creates a new Walker instance
@@ -38,6 +220,7 @@ func NewWalker(rootPath string, numWorkers int) *Walker {
 	return &Walker{
 		rootPath:   rootPath,
 		numWorkers: numWorkers,
+		dirWorkers: numWorkers,
 		excludeDirs: map[string]bool{
 			".git":         true,
 			".svn":         true,
@@ -58,11 +241,29 @@ func NewWalker(rootPath string, numWorkers int) *Walker {
 		},
 		includeHidden:   false,
 		excludePatterns: make([]string, 0),
+		filter:          NewPathFilter(),
+		langFilter:      &LanguageFilter{},
+		testPatterns:    make([]string, 0),
 		results:         make([]*FileStats, 0),
 		errors:          make([]error, 0),
+		projects:        make(map[string][]string),
+		visitedDirs:     make(map[fileID]bool),
+		visitedFiles:    make(map[fileID]bool),
+		skipped:         make(SkipStats),
+		unrecognized:    make(map[string]*UnrecognizedStats),
 	}
 }
 
+// NewFSWalker creates a Walker reading from fsys instead of the OS
+// filesystem, e.g. an os.DirFS, embed.FS, zip.Reader or fstest.MapFS. The
+// root path is a path inside fsys, "." for its root, and counted files are
+// reported below it.
+func NewFSWalker(fsys fs.FS, rootPath string, numWorkers int) *Walker {
+	w := NewWalker(rootPath, numWorkers)
+	w.fsys = fsys
+	return w
+}
+
 // SetExcludeDirs sets custom directories to exclude
 func (w *Walker) SetExcludeDirs(dirs []string) {
 	w.excludeDirs = make(map[string]bool)
@@ -86,13 +287,140 @@ func (w *Walker) AddExcludePattern(pattern string) {
 	w.excludePatterns = append(w.excludePatterns, pattern)
 }
 
+// SetPathFilter sets the include and exclude patterns matched against paths
+// relative to the root
+func (w *Walker) SetPathFilter(filter *PathFilter) {
+	w.filter = filter
+}
+
+// SetLanguageFilter sets which languages are counted
+func (w *Walker) SetLanguageFilter(filter *LanguageFilter) {
+	w.langFilter = filter
+}
+
+// SetLanguageOverrides maps lower-case extensions to the language counting
+// them, taking precedence over the registry
+func (w *Walker) SetLanguageOverrides(overrides map[string]*Language) {
+	w.langOverrides = overrides
+}
+
+// SetTestPatterns sets additional glob patterns that mark files as test code
+func (w *Walker) SetTestPatterns(patterns []string) {
+	w.testPatterns = patterns
+}
+
+// AddTestPattern adds a glob pattern that marks files as test code
+func (w *Walker) AddTestPattern(pattern string) {
+	w.testPatterns = append(w.testPatterns, pattern)
+}
+
 // SetIncludeHidden sets whether to include hidden files
 func (w *Walker) SetIncludeHidden(include bool) {
 	w.includeHidden = include
 }
 
+// SetDirWorkers sets the maximum number of directories read concurrently
+func (w *Walker) SetDirWorkers(n int) {
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	w.dirWorkers = n
+}
+
+// SetFollowSymlinks sets whether symlinked directories and files are followed.
+// Symlink loops are reported as errors and files reachable by multiple paths
+// are counted once.
+func (w *Walker) SetFollowSymlinks(follow bool) {
+	w.followSymlinks = follow
+}
+
+// SetSymlinkFilter sets a function deciding from the absolute, resolved path of
+// a symlink's target whether the symlink is read, whether followed or not.
+// Symlinks that are rejected or cannot be resolved are skipped. The filter only
+// applies to walks of the disk, not of an fs.FS.
+func (w *Walker) SetSymlinkFilter(allow func(target string) bool) {
+	w.symlinkFilter = allow
+}
+
+// SetOneFileSystem sets whether the walk stays on the root's filesystem
+func (w *Walker) SetOneFileSystem(one bool) {
+	w.oneFileSystem = one
+}
+
+// SetFileSizeLimits sets the size range in bytes of files that are counted.
+// A limit of 0 disables it.
+func (w *Walker) SetFileSizeLimits(minSize, maxSize int64) {
+	w.minFileSize = minSize
+	w.maxFileSize = maxSize
+}
+
+// SetMaxDepth sets how many directory levels below the root are read; files
+// directly in the root are at depth 1. A depth of 0 means no limit.
+func (w *Walker) SetMaxDepth(depth int) {
+	w.maxDepth = depth
+}
+
+// SetCountOther sets whether files without a registered language are counted
+// as plain text under the "Other" language instead of being skipped
+func (w *Walker) SetCountOther(count bool) {
+	w.countOther = count
+}
+
+// SetArchives sets whether zip, jar and tar archives are read like
+// directories, counting their entries under paths such as
+// release.tgz!/src/main.go, instead of being skipped as binary files
+func (w *Walker) SetArchives(read bool) {
+	w.archives = read
+}
+
+// SetHashContent sets whether the SHA-256 of every counted file is recorded in
+// FileStats.Hash, so that changes keeping the line counts can be detected
+func (w *Walker) SetHashContent(hash bool) {
+	w.hashContent = hash
+}
+
+// SetFileTimeout sets how long reading a single file may take before it is
+// abandoned and reported as an error. A timeout of 0 disables the limit.
+func (w *Walker) SetFileTimeout(timeout time.Duration) {
+	w.fileTimeout = timeout
+}
+
+// SetFiles restricts the walk to the given files and directories, relative to
+// the root or, without an fs.FS, absolute, instead of the whole tree. Listed files are still
+// subject to the filters, including excluded directories on their path.
+func (w *Walker) SetFiles(paths []string) {
+	w.files = paths
+	w.useFiles = true
+}
+
 // Walk traverses the directory tree and processes files concurrently
 func (w *Walker) Walk() ([]*FileStats, []error) {
+	return w.WalkContext(context.Background())
+}
+
+// WalkContext is like Walk but stops traversal and counting promptly when ctx
+// is done. The results counted so far are returned and IsPartial reports true;
+// the cancellation itself is not reported as an error.
+func (w *Walker) WalkContext(ctx context.Context) ([]*FileStats, []error) {
+	errs := w.WalkFunc(ctx, func(stats *FileStats) {
+		w.results = append(w.results, stats)
+	})
+
+	// Results arrive in completion order; sort them so reports are reproducible
+	sort.Slice(w.results, func(i, j int) bool {
+		return w.results[i].FilePath < w.results[j].FilePath
+	})
+
+	return w.results, errs
+}
+
+// WalkFunc is like WalkContext but streams results instead of accumulating them:
+// fn is called for each file as soon as it is counted, in completion order.
+// Calls are made from a single goroutine, so fn needs no locking, but a slow fn
+// holds up the workers.
+func (w *Walker) WalkFunc(ctx context.Context, fn func(stats *FileStats)) []error {
+	w.ctx = ctx
+	w.onFile = fn
 	jobs := make(chan FileJob, 1000)
 	results := make(chan CountResult, 1000)
 
@@ -108,137 +436,733 @@ func (w *Walker) Walk() ([]*FileStats, []error) {
 	collectWg.Add(1)
 	go w.collectResults(results, &collectWg)
 
-	// Walk the directory tree and send jobs
-	err := filepath.Walk(w.rootPath, func(path string, info os.FileInfo, err error) error {
+	// Walk the directory tree and send jobs; the walking goroutine itself
+	// counts as one directory reader
+	w.dirSem = make(chan struct{}, w.dirWorkers-1)
+	w.walkRoot(jobs)
+
+	// Close jobs channel and wait for workers to finish
+	close(jobs)
+	wg.Wait()
+
+	// Close results channel and wait for collector to finish
+	close(results)
+	collectWg.Wait()
+
+	// Errors arrive in completion order; sort them so reports are reproducible
+	sortErrors(w.errors)
+
+	if ctx.Err() != nil {
+		w.mu.Lock()
+		w.partial = true
+		w.mu.Unlock()
+	}
+
+	return w.errors
+}
+
+// IsPartial reports whether the last walk was canceled before it completed
+func (w *Walker) IsPartial() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.partial
+}
+
+// walkRoot starts the traversal at the walker's root path
+func (w *Walker) walkRoot(jobs chan<- FileJob) {
+	info, err := statPath(w.fsys, w.rootPath)
+	if err != nil {
+		LogDebug("Error accessing path %s: %v", w.rootPath, err)
+		w.addError(err)
+		return
+	}
+
+	if !info.IsDir() {
+		w.handleFile(w.rootPath, info.Name(), fs.FileInfoToDirEntry(info), jobs)
+		return
+	}
+
+	id, ok := getFileID(info)
+	if ok {
+		w.rootDev = id.dev
+		w.visitedDirs[id] = true
+	}
+	if w.useFiles {
+		w.walkFiles(id, jobs)
+	} else {
+		w.walkDir(w.rootPath, []fileID{id}, jobs)
+	}
+	w.dirWg.Wait()
+}
+
+// walkFiles processes the paths given to SetFiles, walking listed directories.
+// Files are identified so that a file listed both directly and through its
+// directory is counted once.
+func (w *Walker) walkFiles(rootID fileID, jobs chan<- FileJob) {
+	seen := make(map[string]bool)
+	for _, name := range w.files {
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		if w.fsys != nil {
+			name = filepath.Join(w.rootPath, name)
+		}
+		path, rel, err := w.resolvePath(name)
 		if err != nil {
-			LogDebug("Error accessing path %s: %v", path, err)
-			w.mu.Lock()
-			w.errors = append(w.errors, err)
-			w.mu.Unlock()
-			return nil // Continue walking despite errors
+			w.addError(err)
+			continue
 		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
 
-		// Skip directories
-		if info.IsDir() {
-			dirName := info.Name()
+		info, err := statPath(w.fsys, path)
+		if errors.Is(err, fs.ErrNotExist) {
+			LogDebug("Skipping %s: it does not exist", path)
+			w.addSkipped(SkipMissing, path)
+			continue
+		}
+		if err != nil {
+			LogDebug("Error accessing path %s: %v", path, err)
+			w.addError(err)
+			continue
+		}
 
-			// Skip excluded directories
-			if w.excludeDirs[dirName] {
-				LogDebug("Skipping excluded directory: %s", path)
-				return filepath.SkipDir
+		if decision := w.dirsExplanation(path, rel); decision != nil {
+			if !info.IsDir() {
+				LogDebug("Skipping %s: %s", path, decision.Detail)
+				w.addSkipped(decision.Reason, path)
+			} else if decision.Reason == SkipDepth {
+				w.skipTree(path)
 			}
+			continue
+		}
 
-			// Skip hidden directories unless configured otherwise
-			if !w.includeHidden && strings.HasPrefix(dirName, ".") && dirName != "." {
-				LogDebug("Skipping hidden directory: %s", path)
-				return filepath.SkipDir
+		if info.IsDir() {
+			if rel == "." {
+				w.walkDir(w.rootPath, []fileID{rootID}, jobs)
+				continue
 			}
-
-			// Check against exclude patterns
-			for _, pattern := range w.excludePatterns {
-				match, err := filepath.Match(pattern, dirName)
-				if err == nil && match {
-					LogDebug("Skipping directory matching pattern %s: %s", pattern, path)
-					return filepath.SkipDir
-				}
+			if w.skipDir(path, info.Name()) {
+				continue
 			}
+			w.enterDir(path, info, w.ancestors(rootID, rel), jobs)
+			continue
+		}
+		if w.isDuplicateFile(path, info) {
+			continue
+		}
+		w.handleFile(path, info.Name(), fs.FileInfoToDirEntry(info), jobs)
+	}
+}
 
-			return nil
+// ancestors returns the root followed by placeholder identities for the
+// directories down to the parent of rel, so depth limits apply to listed
+// directories
+func (w *Walker) ancestors(rootID fileID, rel string) []fileID {
+	ancestors := []fileID{rootID}
+	if dir := filepath.Dir(rel); dir != "." {
+		for range strings.Split(dir, string(filepath.Separator)) {
+			ancestors = append(ancestors, fileID{})
 		}
+	}
+	return ancestors
+}
 
-		fileName := info.Name()
-		ext := strings.ToLower(filepath.Ext(path))
+// resolvePath returns the path below the root of a file given relative to the
+// root or absolute, or as a path inside the walker's fs.FS, and its path
+// relative to the root
+func (w *Walker) resolvePath(name string) (string, string, error) {
+	root, target := w.rootPath, name
+	if w.fsys == nil {
+		rootAbs, err := filepath.Abs(w.rootPath)
+		if err != nil {
+			return "", "", err
+		}
+		root = rootAbs
+		if !filepath.IsAbs(name) {
+			target = filepath.Join(rootAbs, name)
+		}
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("%s is not inside %s", name, w.rootPath)
+	}
+	return filepath.Join(w.rootPath, rel), rel, nil
+}
 
-		// Check against exclude patterns
-		for _, pattern := range w.excludePatterns {
-			match, err := filepath.Match(pattern, fileName)
-			if err == nil && match {
-				LogDebug("Skipping file matching pattern %s: %s", pattern, path)
-				w.mu.Lock()
-				w.skippedFiles++
-				w.mu.Unlock()
-				return nil
-			}
+// dirsExplanation reports a file skipped because a directory on its path
+// below the root is excluded or too deep, or returns nil
+func (w *Walker) dirsExplanation(path, rel string) *Explanation {
+	var dirs []string
+	if dir := filepath.Dir(rel); dir != "." {
+		dirs = strings.Split(dir, string(filepath.Separator))
+	}
+	dirPath := w.rootPath
+	for i, dir := range dirs {
+		dirPath = filepath.Join(dirPath, dir)
+		if detail := w.dirSkipDetail(dirPath, dir); detail != "" {
+			return &Explanation{Path: path, Reason: SkipExcludedDir, Detail: detail}
 		}
+		if w.maxDepth > 0 && i+1 >= w.maxDepth {
+			return &Explanation{Path: path, Reason: SkipDepth,
+				Detail: fmt.Sprintf("it is %d directories deep, beyond --max-depth %d", len(dirs), w.maxDepth)}
+		}
+	}
+	return nil
+}
 
-		// Skip binary files first
-		if IsBinaryExtension(ext) {
-			LogDebug("Skipping binary file: %s", path)
-			w.mu.Lock()
-			w.skippedFiles++
-			w.mu.Unlock()
-			return nil
-		}
-
-		// For hidden files, check if it's a known config file
-		if strings.HasPrefix(fileName, ".") {
-			// Check if it's a known hidden config file
-			lang := GetLanguageByFilename(fileName)
-			if lang != nil {
-				// It's a known config file, process it
-				jobs <- FileJob{
-					Path:      path,
-					Extension: ext,
-					Language:  lang,
+// descend walks a subdirectory in a new goroutine if a directory reader is
+// free, or in the current goroutine otherwise, bounding the number of
+// directories read concurrently
+func (w *Walker) descend(path string, ancestors []fileID, jobs chan<- FileJob) {
+	select {
+	case w.dirSem <- struct{}{}:
+		w.dirWg.Add(1)
+		go func() {
+			defer func() {
+				<-w.dirSem
+				w.dirWg.Done()
+			}()
+			w.walkDir(path, ancestors, jobs)
+		}()
+	default:
+		w.walkDir(path, ancestors, jobs)
+	}
+}
+
+// walkDir reads a directory and processes its entries, descending into
+// subdirectories concurrently.
+// ancestors holds the identities of the directories on the current path and is
+// used to detect symlink loops.
+func (w *Walker) walkDir(dirPath string, ancestors []fileID, jobs chan<- FileJob) {
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	entries, err := readDirPath(w.fsys, dirPath)
+	if err != nil {
+		LogDebug("Error reading directory %s: %v", dirPath, err)
+		w.addError(err)
+		// ReadDir returns the entries read before the error
+	}
+
+	for _, entry := range entries {
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		path := filepath.Join(dirPath, entry.Name())
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if !w.symlinkAllowed(path) {
+				LogDebug("Skipping %s: its target is not allowed", path)
+				w.addSkipped(SkipSymlinkTarget, path)
+				continue
+			}
+			if !w.followSymlinks {
+				// Unfollowed symlinks are treated like regular files, sized by
+				// their targets
+				w.handleFile(path, entry.Name(), nil, jobs)
+				continue
+			}
+
+			info, err := statPath(w.fsys, path)
+			if err != nil {
+				LogDebug("Error following symlink %s: %v", path, err)
+				w.addError(err)
+				continue
+			}
+			if info.IsDir() {
+				if !w.skipDir(path, entry.Name()) {
+					w.enterDir(path, info, ancestors, jobs)
 				}
-				return nil
+				continue
 			}
-			// Unknown hidden file, skip unless includeHidden is set
-			if !w.includeHidden {
-				LogDebug("Skipping unknown hidden file: %s", path)
-				w.mu.Lock()
-				w.skippedFiles++
-				w.mu.Unlock()
-				return nil
+			if w.isDuplicateFile(path, info) {
+				continue
 			}
+			w.handleFile(path, entry.Name(), fs.FileInfoToDirEntry(info), jobs)
+			continue
 		}
 
-		// Try to get language by extension first
-		lang := GetLanguage(ext)
-		if lang == nil {
-			// Try case-sensitive lookup for extensions like .R
-			lang = GetLanguage(filepath.Ext(path))
+		if entry.IsDir() {
+			if w.skipDir(path, entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				w.addError(err)
+				continue
+			}
+			w.enterDir(path, info, ancestors, jobs)
+			continue
 		}
 
-		// If no language found by extension, try by filename
-		if lang == nil {
-			lang = GetLanguageByFilename(fileName)
+		if w.followSymlinks || w.useFiles {
+			info, err := entry.Info()
+			if err != nil {
+				w.addError(err)
+				continue
+			}
+			if w.isDuplicateFile(path, info) {
+				continue
+			}
+			entry = fs.FileInfoToDirEntry(info)
 		}
+		w.handleFile(path, entry.Name(), entry, jobs)
+	}
+}
 
-		// If still no language found, skip the file
-		if lang == nil {
-			LogDebug("Skipping unsupported file: %s", path)
-			w.mu.Lock()
-			w.skippedFiles++
-			w.mu.Unlock()
-			return nil
+// enterDir descends into a directory unless it crosses a filesystem boundary,
+// closes a symlink loop or has already been visited through another path
+func (w *Walker) enterDir(path string, info fs.FileInfo, ancestors []fileID, jobs chan<- FileJob) {
+	if w.maxDepth > 0 && len(ancestors) >= w.maxDepth {
+		LogDebug("Skipping directory below max depth: %s", path)
+		w.skipTree(path)
+		return
+	}
+
+	id, ok := getFileID(info)
+	if !ok {
+		// Without file identities, a path nested this deep can only be a loop
+		if w.followSymlinks && len(ancestors) >= maxSymlinkDepth {
+			target := w.symlinkTarget(path)
+			w.addError(NewSymlinkLoopError(path, target))
+			return
 		}
+		w.descend(path, append(ancestors[:len(ancestors):len(ancestors)], id), jobs)
+		return
+	}
 
-		// Send job to workers
-		jobs <- FileJob{
-			Path:      path,
-			Extension: ext,
-			Language:  lang,
+	if w.oneFileSystem && id.dev != w.rootDev {
+		LogDebug("Skipping directory on another filesystem: %s", path)
+		return
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor == id {
+			target := w.symlinkTarget(path)
+			LogDebug("Symlink loop at %s", path)
+			w.addError(NewSymlinkLoopError(path, target))
+			return
 		}
+	}
 
-		return nil
-	})
+	w.mu.Lock()
+	visited := w.visitedDirs[id]
+	w.visitedDirs[id] = true
+	w.mu.Unlock()
+	if visited {
+		LogDebug("Skipping directory reachable by multiple paths: %s", path)
+		return
+	}
 
+	w.descend(path, append(ancestors[:len(ancestors):len(ancestors)], id), jobs)
+}
+
+// skipTree records the files below a directory pruned by the depth limit as
+// skipped. Only directory listings are read, and directories excluded from the
+// walk are left out.
+func (w *Walker) skipTree(dirPath string) {
+	entries, err := readDirPath(w.fsys, dirPath)
 	if err != nil {
+		LogDebug("Error reading directory %s: %v", dirPath, err)
+	}
+
+	for _, entry := range entries {
+		if w.ctx.Err() != nil {
+			return
+		}
+		path := filepath.Join(dirPath, entry.Name())
+		if entry.IsDir() {
+			if w.dirSkipDetail(path, entry.Name()) == "" {
+				w.skipTree(path)
+			}
+			continue
+		}
+		w.addSkipped(SkipDepth, path)
+	}
+}
+
+// symlinkAllowed reports whether a symlink passes the symlink filter
+func (w *Walker) symlinkAllowed(path string) bool {
+	if w.symlinkFilter == nil || w.fsys != nil {
+		return true
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	if target, err = filepath.Abs(target); err != nil {
+		return false
+	}
+	return w.symlinkFilter(target)
+}
+
+// symlinkTarget returns the target of a symlink for error messages, or an
+// empty string if it cannot be resolved
+func (w *Walker) symlinkTarget(path string) string {
+	if w.fsys != nil {
+		target, _ := fs.ReadLink(w.fsys, fsPath(path))
+		return target
+	}
+	target, _ := filepath.EvalSymlinks(path)
+	return target
+}
+
+// isDuplicateFile reports whether a file has already been seen through another path
+func (w *Walker) isDuplicateFile(path string, info fs.FileInfo) bool {
+	id, ok := getFileID(info)
+	if !ok {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.visitedFiles[id] {
+		LogDebug("Skipping file reachable by multiple paths: %s", path)
+		return true
+	}
+	w.visitedFiles[id] = true
+	return false
+}
+
+// skipDir reports whether a directory is excluded from the walk
+func (w *Walker) skipDir(path, dirName string) bool {
+	if detail := w.dirSkipDetail(path, dirName); detail != "" {
+		LogDebug("Skipping directory %s: %s", path, detail)
+		return true
+	}
+	return false
+}
+
+// dirSkipDetail describes why a directory is excluded from the walk, or
+// returns an empty string if it is walked
+func (w *Walker) dirSkipDetail(path, dirName string) string {
+	// Skip excluded directories
+	if w.excludeDirs[dirName] {
+		return fmt.Sprintf("directory %q is in the excluded directory list", dirName)
+	}
+
+	// Skip hidden directories unless configured otherwise
+	if !w.includeHidden && strings.HasPrefix(dirName, ".") && dirName != "." {
+		return fmt.Sprintf("directory %q is hidden (use --hidden to include it)", dirName)
+	}
+
+	// Check against path globs relative to the root
+	if reason := w.filter.DirReason(filepath.ToSlash(w.relPath(path))); reason != "" {
+		return fmt.Sprintf("directory %q %s", dirName, reason)
+	}
+
+	// Check against exclude patterns
+	for _, pattern := range w.excludePatterns {
+		match, err := filepath.Match(pattern, dirName)
+		if err == nil && match {
+			return fmt.Sprintf("directory %q matches ignore pattern %q", dirName, pattern)
+		}
+	}
+
+	return ""
+}
+
+// handleFile classifies a file and sends it to the workers unless it is
+// skipped. The file is sized from entry, or by following path if entry is nil.
+func (w *Walker) handleFile(path, fileName string, entry fs.DirEntry, jobs chan<- FileJob) {
+	// Record project roots by their manifest files
+	if IsProjectManifest(fileName) {
+		dir := filepath.ToSlash(filepath.Dir(w.relPath(path)))
 		w.mu.Lock()
-		w.errors = append(w.errors, err)
+		w.projects[dir] = append(w.projects[dir], fileName)
 		w.mu.Unlock()
 	}
 
-	// Close jobs channel and wait for workers to finish
-	close(jobs)
-	wg.Wait()
+	// Archives are excluded like directories and read by the workers
+	if w.archives && archiveFormat(fileName) != "" {
+		if w.skipDir(path, fileName) {
+			return
+		}
+		w.sendJob(jobs, FileJob{
+			Path:      path,
+			Extension: strings.ToLower(filepath.Ext(path)),
+			Archive:   true,
+		})
+		return
+	}
 
-	// Close results channel and wait for collector to finish
-	close(results)
-	collectWg.Wait()
+	content := &walkedFile{fsys: w.fsys, path: path, entry: entry}
+	decision, err := w.classify(path, fileName, content)
+	if err != nil {
+		w.addError(NewFileError(path, err))
+		return
+	}
+	if decision.Unrecognized {
+		size, _ := content.Size()
+		w.addUnrecognized(fileName, size)
+	}
+	if !decision.Counted() {
+		LogDebug("Skipping %s: %s", path, decision.Detail)
+		w.addSkipped(decision.Reason, path)
+		return
+	}
+
+	w.sendJob(jobs, FileJob{
+		Path:      path,
+		Extension: strings.ToLower(filepath.Ext(path)),
+		Language:  decision.Language,
+		Hash:      w.hashContent,
+	})
+}
+
+// fileContent gives access to the size and content of a classified file
+type fileContent interface {
+	Size() (int64, error)
+	IsBinary() (bool, error)
+}
+
+// walkedFile is a file of the walked tree being classified. Its size comes
+// from the directory entry read by the walk when there is one.
+type walkedFile struct {
+	fsys  fs.FS
+	path  string
+	entry fs.DirEntry
+	info  fs.FileInfo
+}
+
+// Size returns the size of the file
+func (f *walkedFile) Size() (int64, error) {
+	if f.info == nil {
+		var err error
+		if f.entry != nil {
+			f.info, err = f.entry.Info()
+		} else {
+			f.info, err = statPath(f.fsys, f.path)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return f.info.Size(), nil
+}
+
+// IsBinary reports whether the start of the file contains a NUL byte
+func (f *walkedFile) IsBinary() (bool, error) {
+	return hasBinaryContent(f.fsys, f.path)
+}
 
-	return w.results, w.errors
+// classifyFile decides whether a file is counted and as which language
+func (w *Walker) classifyFile(path, fileName string) (*Explanation, error) {
+	return w.classify(path, fileName, &walkedFile{fsys: w.fsys, path: path})
+}
+
+// classify decides whether a file is counted and as which language, reading
+// its size and content from content only when an option needs them
+func (w *Walker) classify(path, fileName string, content fileContent) (*Explanation, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	unrecognized := false
+	skip := func(reason SkipReason, format string, args ...interface{}) (*Explanation, error) {
+		return &Explanation{Path: path, Reason: reason, Detail: fmt.Sprintf(format, args...), Unrecognized: unrecognized}, nil
+	}
+
+	// Check against exclude patterns
+	for _, pattern := range w.excludePatterns {
+		match, err := filepath.Match(pattern, fileName)
+		if err == nil && match {
+			return skip(SkipIgnorePattern, "file name matches ignore pattern %q", pattern)
+		}
+	}
+
+	// Check against include and exclude path filters
+	if reason := w.filter.Reason(filepath.ToSlash(w.relPath(path))); reason != "" {
+		return skip(SkipPathFilter, "path %s", reason)
+	}
+
+	// Skip binary files first, unless their extension is mapped to a language
+	if IsBinaryExtension(ext) && w.langOverrides[ext] == nil {
+		return skip(SkipBinary, "extension %q is a binary format", ext)
+	}
+
+	// Hidden files are only counted if they are known config files, unless
+	// hidden files are included
+	if strings.HasPrefix(fileName, ".") && !w.includeHidden && GetLanguageByFilename(fileName) == nil {
+		return skip(SkipHidden, "hidden file (use --hidden to include it)")
+	}
+
+	// If no language is found, skip the file
+	lang, match := lookupLanguage(w.langOverrides, path, fileName)
+	unrecognized = lang == nil
+	if lang == nil && !w.countOther {
+		if ext == "" {
+			return skip(SkipUnsupported, "no language is registered for file name %q", fileName)
+		}
+		return skip(SkipUnsupported, "no language is registered for extension %q", filepath.Ext(path))
+	}
+	if lang == nil {
+		binary, err := content.IsBinary()
+		if err != nil {
+			return nil, err
+		}
+		if binary {
+			return skip(SkipBinary, "no language is registered and the content is binary")
+		}
+		lang, match = otherLanguage, "the --other option (no registered language)"
+	}
+
+	if !w.langFilter.Match(lang.Name) {
+		return skip(SkipLanguage, "language %s is filtered out by --lang or --exclude-lang", lang.Name)
+	}
+
+	if w.minFileSize > 0 || w.maxFileSize > 0 {
+		size, err := content.Size()
+		if err != nil {
+			return nil, err
+		}
+		if w.maxFileSize > 0 && size > w.maxFileSize {
+			return skip(SkipTooLarge, "size %d bytes exceeds --max-file-size %d", size, w.maxFileSize)
+		}
+		if size < w.minFileSize {
+			return skip(SkipTooSmall, "size %d bytes is below --min-file-size %d", size, w.minFileSize)
+		}
+	}
+
+	return &Explanation{Path: path, Language: lang, Detail: match, Unrecognized: unrecognized}, nil
+}
+
+// hasBinaryContent reports whether the start of a file contains a NUL byte
+func hasBinaryContent(fsys fs.FS, path string) (bool, error) {
+	file, err := openPath(fsys, path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, binarySniffSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return bytes.IndexByte(buf[:n], 0) >= 0, nil
+}
+
+// lookupLanguage detects a file's language, trying the overrides of the
+// lower-case extension first, then a known file name for hidden files, then the
+// extension (lowercased, then as-is for extensions like .R) and then the file
+// name. It also describes what matched.
+func lookupLanguage(overrides map[string]*Language, path, fileName string) (*Language, string) {
+	if lang := overrides[strings.ToLower(filepath.Ext(path))]; lang != nil {
+		return lang, fmt.Sprintf("extension %q, mapped in the config file", strings.ToLower(filepath.Ext(path)))
+	}
+	if strings.HasPrefix(fileName, ".") {
+		if lang := GetLanguageByFilename(fileName); lang != nil {
+			return lang, fmt.Sprintf("file name %q", fileName)
+		}
+	}
+
+	ext := filepath.Ext(path)
+	if lang := GetLanguage(strings.ToLower(ext)); lang != nil {
+		return lang, fmt.Sprintf("extension %q", strings.ToLower(ext))
+	}
+	if lang := GetLanguage(ext); lang != nil {
+		return lang, fmt.Sprintf("extension %q", ext)
+	}
+	if lang := GetLanguageByFilename(fileName); lang != nil {
+		return lang, fmt.Sprintf("file name %q", fileName)
+	}
+	return nil, ""
+}
+
+// Explain reports why a file below the root would be counted as a language or
+// skipped, applying the same rules as the walk
+func (w *Walker) Explain(path string) (*Explanation, error) {
+	info, err := statPath(w.fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	pathAbs := path
+	if w.fsys == nil {
+		if pathAbs, err = filepath.Abs(path); err != nil {
+			return nil, err
+		}
+	}
+	resolved, rel, err := w.resolvePath(pathAbs)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not inside %s", path, w.rootPath)
+	}
+	path = resolved
+
+	// Directories on the way to the file may be excluded or too deep
+	if decision := w.dirsExplanation(path, rel); decision != nil {
+		return decision, nil
+	}
+
+	decision, err := w.classifyFile(path, filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if decision.Counted() {
+		decision.IsTest = IsTestFile(rel, decision.Language.Name, w.testPatterns)
+	}
+	return decision, nil
+}
+
+// sendJob sends a job to the workers unless the walk is canceled
+func (w *Walker) sendJob(jobs chan<- FileJob, job FileJob) {
+	select {
+	case jobs <- job:
+	case <-w.ctx.Done():
+	}
+}
+
+// sortErrors sorts errors by message
+func sortErrors(errs []error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+}
+
+// addError records an error encountered during the walk
+func (w *Walker) addError(err error) {
+	w.mu.Lock()
+	w.errors = append(w.errors, err)
+	w.mu.Unlock()
+}
+
+// addUnrecognized records a file without a registered language
+func (w *Walker) addUnrecognized(fileName string, size int64) {
+	name := unrecognizedName(fileName)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats, exists := w.unrecognized[name]
+	if !exists {
+		stats = &UnrecognizedStats{Name: name}
+		w.unrecognized[name] = stats
+	}
+	stats.Files++
+	stats.Bytes += size
+}
+
+// unrecognizedName returns the name a file without a registered language is
+// reported under: its extension, or its file name if it has no extension
+func unrecognizedName(path string) string {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != "" {
+		return ext
+	}
+	return filepath.Base(path)
+}
+
+// addSkipped records a skipped file and the reason it was skipped
+func (w *Walker) addSkipped(reason SkipReason, path string) {
+	w.mu.Lock()
+	w.skippedFiles++
+	w.skipped.add(reason, path)
+	w.mu.Unlock()
 }
 
 // worker processes files from the jobs channel
@@ -246,9 +1170,20 @@ func (w *Walker) worker(jobs <-chan FileJob, results chan<- CountResult, wg *syn
 	defer wg.Done()
 
 	for job := range jobs {
-		stats, err := CountLines(job.Path, job.Language)
+		// Drain remaining jobs without counting once canceled
+		if w.ctx.Err() != nil {
+			continue
+		}
+
+		if job.Archive {
+			w.countArchive(job, results)
+			continue
+		}
+
+		stats, err := w.countFile(job)
 		if stats != nil {
 			stats.Extension = job.Extension
+			stats.IsTest = IsTestFile(w.relPath(job.Path), job.Language.Name, w.testPatterns)
 		}
 		results <- CountResult{
 			Stats: stats,
@@ -257,19 +1192,150 @@ func (w *Walker) worker(jobs <-chan FileJob, results chan<- CountResult, wg *syn
 	}
 }
 
+// countArchive counts the entries of an archive. Entries are read in archive
+// order from a single stream, so the file timeout does not apply to them.
+func (w *Walker) countArchive(job FileJob, results chan<- CountResult) {
+	err := readArchive(w.fsys, job.Path, func(entry *archiveEntry) error {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		if result, ok := w.countEntry(job.Path, entry); ok {
+			results <- result
+		}
+		return nil
+	})
+	if err != nil && w.ctx.Err() == nil {
+		results <- CountResult{Error: NewFileError(job.Path, err)}
+	}
+}
+
+// countEntry classifies and counts an archive entry as if the archive were a
+// directory, reporting whether it produced a result
+func (w *Walker) countEntry(archivePath string, entry *archiveEntry) (CountResult, bool) {
+	virtualPath := filepath.Join(archivePath, filepath.FromSlash(entry.name))
+	displayPath := archivePath + ArchiveSeparator + entry.name
+	rel := w.relPath(virtualPath)
+	fileName := path.Base(entry.name)
+
+	// Entries below excluded directories are ignored like in a walk
+	if decision := w.dirsExplanation(virtualPath, rel); decision != nil {
+		return CountResult{}, false
+	}
+	if entry.err != nil {
+		return CountResult{Error: NewFileError(displayPath, entry.err)}, true
+	}
+
+	decision, err := w.classify(virtualPath, fileName, entry)
+	if err != nil {
+		return CountResult{Error: NewFileError(displayPath, err)}, true
+	}
+	if decision.Unrecognized {
+		w.addUnrecognized(fileName, entry.size)
+	}
+	if !decision.Counted() {
+		LogDebug("Skipping %s: %s", displayPath, decision.Detail)
+		w.addSkipped(decision.Reason, displayPath)
+		return CountResult{}, false
+	}
+
+	stats, err := countContent(entry.r, displayPath, decision.Language, w.hashContent)
+	if err != nil {
+		return CountResult{Error: NewFileError(displayPath, err)}, true
+	}
+	stats.Extension = strings.ToLower(filepath.Ext(fileName))
+	stats.IsTest = IsTestFile(rel, decision.Language.Name, w.testPatterns)
+	return CountResult{Stats: stats}, true
+}
+
+// countFile counts a file, giving up when the file timeout expires or the
+// walk is canceled while waiting for it. A read that hangs, e.g. on an
+// unresponsive network mount, cannot be interrupted, so it is left running in
+// the background. Without a file timeout the file is counted directly and
+// cancellation is noticed between files.
+func (w *Walker) countFile(job FileJob) (*FileStats, error) {
+	if w.fileTimeout <= 0 {
+		return countJob(w.fsys, job)
+	}
+
+	done := make(chan CountResult, 1)
+	go func() {
+		stats, err := countJob(w.fsys, job)
+		done <- CountResult{Stats: stats, Error: err}
+	}()
+
+	timer := time.NewTimer(w.fileTimeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result.Stats, result.Error
+	case <-timer.C:
+		LogDebug("Timed out reading %s", job.Path)
+		return nil, NewFileError(job.Path, ErrReadTimeout)
+	case <-w.ctx.Done():
+		return nil, nil
+	}
+}
+
+// countJob counts a file with its language's syntax, or as plain text if it
+// has no registered language
+func countJob(fsys fs.FS, job FileJob) (*FileStats, error) {
+	file, err := openPath(fsys, job.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return countContent(file, job.Path, job.Language, job.Hash)
+}
+
+// countContent counts the content read from r like countJob, reporting it
+// under the given path and recording its SHA-256 if withHash is set
+func countContent(r io.Reader, path string, lang *Language, withHash bool) (*FileStats, error) {
+	var h hash.Hash
+	if withHash {
+		h = sha256.New()
+		r = io.TeeReader(r, h)
+	}
+
+	var stats *FileStats
+	var err error
+	if lang == otherLanguage {
+		stats, err = CountReaderGeneric(r, path)
+		if stats != nil {
+			stats.Language = otherLanguage.Name
+		}
+	} else {
+		stats, err = CountReader(r, path, lang)
+	}
+	if stats != nil && h != nil {
+		stats.Hash = hex.EncodeToString(h.Sum(nil))
+	}
+	return stats, err
+}
+
+// relPath returns the path relative to the walker's root path
+func (w *Walker) relPath(path string) string {
+	rel, err := filepath.Rel(w.rootPath, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
 // collectResults collects results from the results channel
 func (w *Walker) collectResults(results <-chan CountResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for result := range results {
-		w.mu.Lock()
 		if result.Error != nil {
-			w.errors = append(w.errors, result.Error)
+			w.addError(result.Error)
 		} else if result.Stats != nil {
-			w.results = append(w.results, result.Stats)
+			w.onFile(result.Stats)
+			w.mu.Lock()
 			w.processedFiles++
+			w.mu.Unlock()
 		}
-		w.mu.Unlock()
 	}
 }
 
@@ -287,6 +1353,75 @@ func (w *Walker) GetSkippedCount() int {
 	return w.skippedFiles
 }
 
+// GetSkipReasons returns the number of skipped files per reason
+func (w *Walker) GetSkipReasons() map[SkipReason]int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	reasons := make(map[SkipReason]int, len(w.skipped))
+	for reason := range w.skipped {
+		reasons[reason] = w.skipped.Count(reason)
+	}
+	return reasons
+}
+
+// GetSkipStats returns the number of skipped files per reason and extension
+func (w *Walker) GetSkipStats() SkipStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := make(SkipStats, len(w.skipped))
+	for reason, exts := range w.skipped {
+		stats[reason] = make(map[string]int, len(exts))
+		for ext, count := range exts {
+			stats[reason][ext] = count
+		}
+	}
+	return stats
+}
+
+// GetUnrecognized returns the extensions and file names without a registered
+// language, sorted by file count (descending), then name
+func (w *Walker) GetUnrecognized() []*UnrecognizedStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	unrecognized := make([]*UnrecognizedStats, 0, len(w.unrecognized))
+	for _, stats := range w.unrecognized {
+		copied := *stats
+		unrecognized = append(unrecognized, &copied)
+	}
+	sortUnrecognized(unrecognized)
+	return unrecognized
+}
+
+// sortUnrecognized sorts unrecognized files by file count (descending), then name
+func sortUnrecognized(unrecognized []*UnrecognizedStats) {
+	sort.Slice(unrecognized, func(i, j int) bool {
+		if unrecognized[i].Files != unrecognized[j].Files {
+			return unrecognized[i].Files > unrecognized[j].Files
+		}
+		return unrecognized[i].Name < unrecognized[j].Name
+	})
+}
+
+// GetProjects returns the project roots detected during the walk, sorted by path
+func (w *Walker) GetProjects() []Project {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	projects := make([]Project, 0, len(w.projects))
+	for path, manifests := range w.projects {
+		sorted := append([]string(nil), manifests...)
+		sort.Strings(sorted)
+		projects = append(projects, Project{Path: path, Manifests: sorted})
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Path < projects[j].Path
+	})
+	return projects
+}
+
 // GetErrorCount returns the number of errors encountered
 func (w *Walker) GetErrorCount() int {
 	w.mu.Lock()
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+// benchTreeFiles is the size of the generated tree used by the walker benchmarks
+const benchTreeFiles = 200000
+
+var (
+	benchTreeOnce sync.Once
+	benchTreeDir  string
+)
+
+// benchTree generates the benchmark tree once per test binary run
+func benchTree(b *testing.B) string {
+	b.Helper()
+	benchTreeOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "walker-bench")
+		if err != nil {
+			b.Fatalf("Failed to create temp dir: %v", err)
+		}
+		generateTree(b, dir, benchTreeFiles)
+		benchTreeDir = dir
+	})
+	if benchTreeDir == "" {
+		b.Fatal("benchmark tree was not generated")
+	}
+	return benchTreeDir
+}
+
+func benchmarkWalk(b *testing.B, dirWorkers int) {
+	root := benchTree(b)
+	SetLogLevel(LogLevelSilent)
+	defer SetLogLevel(LogLevelInfo)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		walker := NewWalker(root, 0)
+		walker.SetDirWorkers(dirWorkers)
+		walker.AddExcludeDir("skip")
+		walker.AddExcludePattern("*_gen.go")
+		walker.Walk()
+	}
+}
+
+func BenchmarkWalkSequentialDirs(b *testing.B) {
+	benchmarkWalk(b, 1)
+}
+
+func BenchmarkWalkParallelDirs(b *testing.B) {
+	benchmarkWalk(b, 0)
+}
+
+func BenchmarkWalkParallelDirs32(b *testing.B) {
+	benchmarkWalk(b, 32)
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestNewWalker(t *testing.T) {
@@ -418,3 +427,622 @@ func TestWalkerSkippedCount(t *testing.T) {
 		t.Errorf("Expected 1 processed file, got %d", walker.GetProcessedCount())
 	}
 }
+
+func TestWalkerFollowSymlinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// real/lib.go is reachable directly and through packages/lib
+	os.MkdirAll(filepath.Join(tmpDir, "real"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "packages"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "real", "lib.go"), []byte("package lib\n"), 0644)
+
+	// external/ is only reachable through a symlink
+	external, err := os.MkdirTemp("", "walker-external")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(external)
+	os.WriteFile(filepath.Join(external, "ext.go"), []byte("package ext\n"), 0644)
+
+	if err := os.Symlink(filepath.Join(tmpDir, "real"), filepath.Join(tmpDir, "packages", "lib")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	os.Symlink(external, filepath.Join(tmpDir, "packages", "ext"))
+
+	// Without following, symlinked directories are ignored
+	walker := NewWalker(tmpDir, 2)
+	stats, _ := walker.Walk()
+	if len(stats) != 1 {
+		t.Errorf("Expected 1 file without following symlinks, got %d", len(stats))
+	}
+
+	// Following counts the external file once and real/lib.go once
+	walker = NewWalker(tmpDir, 2)
+	walker.SetFollowSymlinks(true)
+	stats, errors := walker.Walk()
+	if len(errors) > 0 {
+		t.Errorf("Walk returned errors: %v", errors)
+	}
+	if len(stats) != 2 {
+		t.Errorf("Expected 2 files following symlinks, got %d", len(stats))
+		for _, s := range stats {
+			t.Logf("Found: %s", s.FilePath)
+		}
+	}
+}
+
+func TestWalkerSymlinkLoop(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.MkdirAll(filepath.Join(tmpDir, "a", "b"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "a", "b", "main.go"), []byte("package main\n"), 0644)
+	if err := os.Symlink(filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "a", "b", "loop")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetFollowSymlinks(true)
+	stats, errs := walker.Walk()
+
+	if len(stats) != 1 {
+		t.Errorf("Expected 1 file, got %d", len(stats))
+	}
+
+	found := false
+	for _, err := range errs {
+		var loopErr *SymlinkLoopError
+		if errors.As(err, &loopErr) {
+			found = true
+			if loopErr.Path != filepath.Join(tmpDir, "a", "b", "loop") {
+				t.Errorf("SymlinkLoopError.Path = %q", loopErr.Path)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a SymlinkLoopError, got %v", errs)
+	}
+}
+
+func TestWalkerOneFileSystem(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "sub", "main.go"), []byte("package main\n"), 0644)
+
+	// Everything is on one filesystem, so nothing is skipped
+	walker := NewWalker(tmpDir, 2)
+	walker.SetOneFileSystem(true)
+	stats, _ := walker.Walk()
+	if len(stats) != 1 {
+		t.Errorf("Expected 1 file, got %d", len(stats))
+	}
+}
+
+func TestWalkerSetDirWorkers(t *testing.T) {
+	walker := NewWalker("/tmp", 4)
+	if walker.dirWorkers != 4 {
+		t.Errorf("dirWorkers = %d, want 4 by default", walker.dirWorkers)
+	}
+
+	walker.SetDirWorkers(16)
+	if walker.dirWorkers != 16 {
+		t.Errorf("dirWorkers = %d, want 16", walker.dirWorkers)
+	}
+
+	walker.SetDirWorkers(0)
+	if walker.dirWorkers <= 0 {
+		t.Errorf("dirWorkers should be positive, got %d", walker.dirWorkers)
+	}
+}
+
+func TestWalkerParallelDirectories(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	generateTree(t, tmpDir, 500)
+
+	for _, dirWorkers := range []int{1, 2, 16} {
+		walker := NewWalker(tmpDir, 4)
+		walker.SetDirWorkers(dirWorkers)
+		walker.AddExcludeDir("skip")
+		walker.AddExcludePattern("*_gen.go")
+		stats, errors := walker.Walk()
+
+		if len(errors) > 0 {
+			t.Errorf("dirWorkers=%d: Walk returned errors: %v", dirWorkers, errors)
+		}
+		if len(stats) != 400 {
+			t.Errorf("dirWorkers=%d: expected 400 files, got %d", dirWorkers, len(stats))
+		}
+		if walker.GetSkippedCount() != 50 {
+			t.Errorf("dirWorkers=%d: expected 50 skipped files, got %d", dirWorkers, walker.GetSkippedCount())
+		}
+	}
+}
+
+// generateTree creates n files spread over nested directories: a tenth of them
+// in excluded "skip" directories and a tenth matching "*_gen.go"
+func generateTree(tb testing.TB, root string, n int) {
+	tb.Helper()
+	for i := 0; i < n; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("d%d", i%40), fmt.Sprintf("e%d", (i/40)%50))
+		name := fmt.Sprintf("f%d.go", i)
+		switch i % 10 {
+		case 0:
+			dir = filepath.Join(dir, "skip")
+		case 1:
+			name = fmt.Sprintf("f%d_gen.go", i)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n\n// comment\nfunc main() {}\n"), 0644); err != nil {
+			tb.Fatalf("Failed to create file: %v", err)
+		}
+	}
+}
+
+func TestWalkerWalkContextCanceled(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	generateTree(t, tmpDir, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	walker := NewWalker(tmpDir, 4)
+	stats, errs := walker.WalkContext(ctx)
+
+	if !walker.IsPartial() {
+		t.Error("expected walk to be partial")
+	}
+	if len(stats) >= 100 {
+		t.Errorf("expected fewer than 100 files from a canceled walk, got %d", len(stats))
+	}
+
+	// Cancellation is reported by IsPartial, not as an error
+	for _, err := range errs {
+		if errors.Is(err, context.Canceled) {
+			t.Errorf("expected no context.Canceled among errors, got %v", errs)
+		}
+	}
+}
+
+func TestWalkerWalkContextComplete(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	generateTree(t, tmpDir, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	walker := NewWalker(tmpDir, 4)
+	walker.SetFileTimeout(time.Minute)
+	stats, errs := walker.WalkContext(ctx)
+
+	if walker.IsPartial() {
+		t.Error("expected walk not to be partial")
+	}
+	if len(errs) > 0 {
+		t.Errorf("WalkContext returned errors: %v", errs)
+	}
+	if len(stats) != 100 {
+		t.Errorf("expected 100 files, got %d", len(stats))
+	}
+}
+
+func TestWalkerWalkFunc(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	generateTree(t, tmpDir, 200)
+
+	walker := NewWalker(tmpDir, 8)
+	seen := make(map[string]bool)
+	errs := walker.WalkFunc(context.Background(), func(stats *FileStats) {
+		if seen[stats.FilePath] {
+			t.Errorf("file %s streamed twice", stats.FilePath)
+		}
+		seen[stats.FilePath] = true
+	})
+
+	if len(errs) > 0 {
+		t.Errorf("WalkFunc returned errors: %v", errs)
+	}
+	if len(seen) != 200 {
+		t.Errorf("expected 200 streamed files, got %d", len(seen))
+	}
+	if walker.GetProcessedCount() != 200 {
+		t.Errorf("expected 200 processed files, got %d", walker.GetProcessedCount())
+	}
+	if len(walker.results) != 0 {
+		t.Errorf("expected WalkFunc not to retain results, got %d", len(walker.results))
+	}
+}
+
+func TestWalkerFileSizeLimits(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "empty.go"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "small.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "dump.sql"), []byte(strings.Repeat("INSERT INTO t VALUES (1);\n", 100)), 0644)
+
+	walker := NewWalker(tmpDir, 2)
+	walker.SetFileSizeLimits(1, 1024)
+	stats, errs := walker.Walk()
+
+	if len(errs) > 0 {
+		t.Errorf("Walk returned errors: %v", errs)
+	}
+	if len(stats) != 1 || filepath.Base(stats[0].FilePath) != "small.go" {
+		t.Errorf("expected only small.go to be counted, got %d files", len(stats))
+	}
+
+	reasons := walker.GetSkipReasons()
+	if reasons[SkipTooLarge] != 1 || reasons[SkipTooSmall] != 1 {
+		t.Errorf("expected one too large and one too small file, got %v", reasons)
+	}
+	if walker.GetSkippedCount() != 2 {
+		t.Errorf("expected 2 skipped files, got %d", walker.GetSkippedCount())
+	}
+}
+
+func TestWalkerMaxDepth(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range []string{"a.go", "one/b.go", "one/two/c.go", "one/two/three/d.go", "one/two/node_modules/e.js"} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("package main\n"), 0644)
+	}
+
+	for depth, want := range map[int]int{0: 4, 1: 1, 2: 2, 3: 3, 10: 4} {
+		walker := NewWalker(tmpDir, 2)
+		walker.SetMaxDepth(depth)
+		stats, _ := walker.Walk()
+		if len(stats) != want {
+			t.Errorf("max depth %d: expected %d files, got %d", depth, want, len(stats))
+		}
+
+		// Files in pruned directories are skipped, except in excluded directories
+		if skipped := walker.GetSkipStats().Count(SkipDepth); skipped != 4-want {
+			t.Errorf("max depth %d: expected %d files skipped by depth, got %d", depth, 4-want, skipped)
+		}
+	}
+}
+
+func TestWalkerSkipReasons(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range []string{"main.go", "app.log", "image.png", ".secret", "data.unknown"} {
+		os.WriteFile(filepath.Join(tmpDir, file), []byte("x\n"), 0644)
+	}
+
+	walker := NewWalker(tmpDir, 2)
+	walker.AddExcludePattern("*.log")
+	walker.Walk()
+
+	want := map[SkipReason]int{
+		SkipIgnorePattern: 1,
+		SkipBinary:        1,
+		SkipHidden:        1,
+		SkipUnsupported:   1,
+	}
+	got := walker.GetSkipReasons()
+	if len(got) != len(want) {
+		t.Errorf("skip reasons = %v, want %v", got, want)
+	}
+	for reason, count := range want {
+		if got[reason] != count {
+			t.Errorf("skip reason %q = %d, want %d", reason, got[reason], count)
+		}
+	}
+}
+
+func TestWalkerSkipStats(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range []string{"a.foo", "b.foo", "c.csv", "Procfile.bak", "NOTICE"} {
+		os.WriteFile(filepath.Join(tmpDir, file), []byte("x\n"), 0644)
+	}
+
+	walker := NewWalker(tmpDir, 2)
+	walker.Walk()
+	skips := walker.GetSkipStats()
+
+	if skips.Count(SkipUnsupported) != 5 {
+		t.Errorf("expected 5 unsupported files, got %d", skips.Count(SkipUnsupported))
+	}
+	want := []string{".foo", noExtension, ".bak", ".csv"}
+	got := skips.Extensions(SkipUnsupported)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("extensions = %v, want %v", got, want)
+	}
+}
+
+func TestWalkerExplain(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range []string{
+		"main.go",
+		"main_test.go",
+		"Makefile",
+		"app.log",
+		"logo.png",
+		".env.secret",
+		"data.xyz",
+		"node_modules/lib/index.js",
+		"one/two/deep.go",
+		"legacy/old.go",
+	} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("x\n"), 0644)
+	}
+
+	filter := NewPathFilter()
+	filter.AddExclude("legacy/")
+
+	walker := NewWalker(tmpDir, 1)
+	walker.AddExcludePattern("*.log")
+	walker.SetPathFilter(filter)
+	walker.SetMaxDepth(2)
+
+	tests := []struct {
+		file     string
+		reason   SkipReason
+		language string
+		isTest   bool
+	}{
+		{"main.go", "", "Go", false},
+		{"main_test.go", "", "Go", true},
+		{"Makefile", "", "Makefile", false},
+		{"app.log", SkipIgnorePattern, "", false},
+		{"logo.png", SkipBinary, "", false},
+		{".env.secret", SkipHidden, "", false},
+		{"data.xyz", SkipUnsupported, "", false},
+		{"node_modules/lib/index.js", SkipExcludedDir, "", false},
+		{"one/two/deep.go", SkipDepth, "", false},
+		{"legacy/old.go", SkipExcludedDir, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			e, err := walker.Explain(filepath.Join(tmpDir, filepath.FromSlash(tt.file)))
+			if err != nil {
+				t.Fatalf("Explain error: %v", err)
+			}
+			if e.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q (%s)", e.Reason, tt.reason, e.Detail)
+			}
+			if e.Detail == "" {
+				t.Error("expected a detail")
+			}
+			if tt.language != "" && (e.Language == nil || e.Language.Name != tt.language) {
+				t.Errorf("Language = %v, want %s", e.Language, tt.language)
+			}
+			if e.IsTest != tt.isTest {
+				t.Errorf("IsTest = %v, want %v", e.IsTest, tt.isTest)
+			}
+		})
+	}
+
+	if _, err := walker.Explain(os.TempDir()); err == nil {
+		t.Error("expected an error for a directory")
+	}
+	if _, err := walker.Explain(filepath.Join(tmpDir, "missing.go")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestWalkerUnrecognized(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "a.foo"), []byte("one\n\ntwo\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "b.FOO"), []byte("three\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "Brewfile"), []byte("brew \"go\"\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "blob.xyz"), []byte("abc\x00def"), 0644)
+
+	t.Run("Skipped", func(t *testing.T) {
+		walker := NewWalker(tmpDir, 2)
+		stats, _ := walker.Walk()
+		if len(stats) != 1 {
+			t.Errorf("expected 1 counted file, got %d", len(stats))
+		}
+
+		got := walker.GetUnrecognized()
+		want := []UnrecognizedStats{
+			{Name: ".foo", Files: 2, Bytes: 15},
+			{Name: ".xyz", Files: 1, Bytes: 7},
+			{Name: "Brewfile", Files: 1, Bytes: 10},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("unrecognized = %d entries, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if *got[i] != want[i] {
+				t.Errorf("unrecognized[%d] = %+v, want %+v", i, *got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Counted as Other", func(t *testing.T) {
+		walker := NewWalker(tmpDir, 2)
+		walker.SetCountOther(true)
+		stats, errs := walker.Walk()
+		if len(errs) > 0 {
+			t.Fatalf("Walk returned errors: %v", errs)
+		}
+
+		langStats := AggregateStats(stats)
+		other := langStats["Other"]
+		if other == nil || other.FileCount != 3 || other.CodeLines != 4 || other.BlankLines != 1 {
+			t.Errorf("unexpected Other stats: %+v", other)
+		}
+		if walker.GetSkipReasons()[SkipBinary] != 1 {
+			t.Errorf("expected the binary file to be skipped, got %v", walker.GetSkipReasons())
+		}
+		// Counted and binary files are reported as unrecognized like without --other
+		if len(walker.GetUnrecognized()) != 3 {
+			t.Errorf("expected counted and binary files to be reported as unrecognized, got %d", len(walker.GetUnrecognized()))
+		}
+	})
+}
+
+func TestFSWalker(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":                   {Data: []byte("package main\n\n// main\nfunc main() {}\n")},
+		"pkg/util/util.go":          {Data: []byte("package util\n")},
+		"pkg/util/util_test.go":     {Data: []byte("package util\n")},
+		"web/app.ts":                {Data: []byte("let a = 1\n")},
+		"node_modules/dep/index.js": {Data: []byte("var dep\n")},
+		".hidden/secret.go":         {Data: []byte("package secret\n")},
+		"image.png":                 {Data: []byte("\x89PNG")},
+		"notes.foo":                 {Data: []byte("abc\n")},
+	}
+
+	walker := NewFSWalker(fsys, ".", 2)
+	stats, errs := walker.Walk()
+	if len(errs) > 0 {
+		t.Fatalf("Walk returned errors: %v", errs)
+	}
+
+	var got []string
+	for _, s := range stats {
+		got = append(got, filepath.ToSlash(s.FilePath))
+	}
+	want := []string{"main.go", "pkg/util/util.go", "pkg/util/util_test.go", "web/app.ts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("counted %q, want %q", got, want)
+	}
+	if stats[0].CodeLines != 2 || stats[0].CommentLines != 1 || stats[0].BlankLines != 1 {
+		t.Errorf("main.go: %+v", stats[0])
+	}
+	if !stats[2].IsTest {
+		t.Error("util_test.go should be marked as a test file")
+	}
+	if walker.GetSkipReasons()[SkipBinary] != 1 || walker.GetSkipReasons()[SkipUnsupported] != 1 {
+		t.Errorf("unexpected skip reasons: %v", walker.GetSkipReasons())
+	}
+	if unrecognized := walker.GetUnrecognized(); len(unrecognized) != 1 || unrecognized[0].Bytes != 4 {
+		t.Errorf("unexpected unrecognized files: %+v", unrecognized)
+	}
+}
+
+func TestFSWalkerSubdirectory(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/a.go":     {Data: []byte("package a\n")},
+		"src/sub/b.go": {Data: []byte("package b\n")},
+		"other/c.go":   {Data: []byte("package c\n")},
+	}
+
+	walker := NewFSWalker(fsys, "src", 2)
+	walker.SetMaxDepth(1)
+	stats, errs := walker.Walk()
+	if len(errs) > 0 {
+		t.Fatalf("Walk returned errors: %v", errs)
+	}
+	if len(stats) != 1 || filepath.ToSlash(stats[0].FilePath) != "src/a.go" {
+		t.Errorf("expected only src/a.go, got %d files", len(stats))
+	}
+
+	explanation, err := walker.Explain("src/sub/b.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.Reason != SkipDepth {
+		t.Errorf("Explain reason = %q, want %q", explanation.Reason, SkipDepth)
+	}
+}
+
+func TestFSWalkerSetFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":     {Data: []byte("package a\n")},
+		"b.go":     {Data: []byte("package b\n")},
+		"sub/c.go": {Data: []byte("package c\n")},
+	}
+
+	walker := NewFSWalker(fsys, ".", 2)
+	walker.SetFiles([]string{"a.go", "sub", "missing.go"})
+	stats, errs := walker.Walk()
+
+	if len(stats) != 2 {
+		t.Errorf("counted %d files, want a.go and sub/c.go", len(stats))
+	}
+	if len(errs) != 0 {
+		t.Errorf("got errors %v, want missing.go to be skipped", errs)
+	}
+	if n := walker.GetSkipStats().Count(SkipMissing); n != 1 {
+		t.Errorf("missing skips = %d, want 1", n)
+	}
+}
+
+func TestFSWalkerArchives(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("src/main.go")
+	w.Write([]byte("package main\n"))
+	zw.Close()
+
+	fsys := fstest.MapFS{"drop.zip": {Data: buf.Bytes()}}
+	walker := NewFSWalker(fsys, ".", 2)
+	walker.SetArchives(true)
+	stats, errs := walker.Walk()
+	if len(errs) > 0 {
+		t.Fatalf("Walk returned errors: %v", errs)
+	}
+	if len(stats) != 1 || filepath.ToSlash(stats[0].FilePath) != "drop.zip!/src/main.go" {
+		t.Errorf("expected drop.zip!/src/main.go, got %d files", len(stats))
+	}
+}
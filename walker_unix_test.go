@@ -0,0 +1,41 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWalkerFileTimeout(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Opening a FIFO without a writer blocks, like a read on a hung mount
+	fifo := filepath.Join(tmpDir, "hang.go")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+
+	walker := NewWalker(tmpDir, 1)
+	walker.SetFileTimeout(50 * time.Millisecond)
+	walker.ctx = context.Background()
+
+	_, err = walker.countFile(FileJob{Path: fifo, Language: GetLanguage(".go")})
+	if !errors.Is(err, ErrReadTimeout) {
+		t.Errorf("expected ErrReadTimeout, got %v", err)
+	}
+
+	// Unblock the abandoned reader
+	if f, err := os.OpenFile(fifo, os.O_WRONLY, 0); err == nil {
+		f.Close()
+	}
+}